@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// APIKeyCmd regroupe les commandes de gestion des clés d'API ('apikey create|list|revoke').
+var APIKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Gère les clés d'accès à l'API REST.",
+	Long: `Les routes /api/v1 exigent une clé d'API, transmise dans l'en-tête
+"Authorization: Bearer <clé>" ou "X-API-Key: <clé>". Chaque clé porte un ou plusieurs scopes :
+  links:write  créer, modifier, supprimer et restaurer des liens
+  links:read   lister les liens
+  stats:read   consulter les statistiques et l'état de santé des liens
+  admin        administration (quarantaine), implique tous les autres scopes
+
+Les redirections restent publiques.`,
+}
+
+// APIKeyCreateCmd représente la commande 'apikey create'
+var APIKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Crée une clé d'API et l'affiche une seule fois.",
+	Long: `Cette commande génère une clé d'API. Seule son empreinte est enregistrée :
+notez la clé affichée, elle ne pourra plus être retrouvée.
+Une clé agit au nom de l'utilisateur --user : elle crée des liens lui appartenant et ne voit que les siens.
+--user est obligatoire sauf avec le scope admin, qui donne accès à tous les liens (sans propriétaire compris).
+
+Exemples:
+  url-shortener apikey create --name="dashboard" --user="marketing" --scopes=links:read,stats:read
+  url-shortener apikey create --name="ci" --user="marketing" --scopes=links:write --expires-in=720h
+  url-shortener apikey create --name="ops" --scopes=admin`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scopes")
+		expiresAtFlag, _ := cmd.Flags().GetString("expires-at")
+		expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+		userName, _ := cmd.Flags().GetString("user")
+
+		if expiresAtFlag != "" && expiresIn != 0 {
+			log.Println("Erreur: --expires-at et --expires-in sont incompatibles")
+			os.Exit(1)
+		}
+		var expiresAt *time.Time
+		if expiresAtFlag != "" {
+			t, err := parseDateFlag(expiresAtFlag, time.Local)
+			if err != nil {
+				log.Printf("Erreur: --expires-at invalide (RFC 3339 ou AAAA-MM-JJ): %v", err)
+				os.Exit(1)
+			}
+			expiresAt = &t
+		} else if expiresIn != 0 {
+			t := time.Now().Add(expiresIn)
+			expiresAt = &t
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		apiKeyService := services.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+
+		var userID *uint
+		if userName != "" {
+			userID = &lookupUser(db, userName).ID
+		}
+
+		key, token, err := apiKeyService.CreateKey(name, userID, scopes, expiresAt)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKeyOptions) {
+				log.Printf("Erreur: %v", err)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la création de la clé d'API: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Clé d'API créée avec succès:\n")
+		fmt.Printf("ID: %d\n", key.ID)
+		fmt.Printf("Nom: %s\n", key.Name)
+		if userName != "" {
+			fmt.Printf("Utilisateur: %s\n", userName)
+		}
+		fmt.Printf("Scopes: %s\n", strings.Join(key.ScopeList(), ", "))
+		if key.ExpiresAt != nil {
+			fmt.Printf("Date d'expiration: %s\n", key.ExpiresAt.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Printf("\nClé: %s\n", token)
+		fmt.Println("Conservez-la maintenant : elle ne sera plus affichée.")
+	},
+}
+
+// APIKeyListCmd représente la commande 'apikey list'
+var APIKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les clés d'API, révoquées comprises.",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		apiKeyService := services.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+
+		keys, err := apiKeyService.ListKeys()
+		if err != nil {
+			log.Printf("Erreur lors de la récupération des clés d'API: %v", err)
+			os.Exit(1)
+		}
+		users, err := services.NewUserService(repository.NewUserRepository(db)).ListUsers()
+		if err != nil {
+			log.Printf("Erreur lors de la récupération des utilisateurs: %v", err)
+			os.Exit(1)
+		}
+		userNames := make(map[uint]string, len(users))
+		for _, u := range users {
+			userNames[u.ID] = u.Name
+		}
+		if len(keys) == 0 {
+			fmt.Println("Aucune clé d'API. Créez-en une avec 'url-shortener apikey create'.")
+			return
+		}
+
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNOM\tUTILISATEUR\tPRÉFIXE\tSCOPES\tÉTAT\tCRÉÉE LE\tEXPIRE LE\tDERNIÈRE UTILISATION")
+		for _, k := range keys {
+			user := "-"
+			if k.UserID != nil {
+				user = userNames[*k.UserID]
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s…\t%s\t%s\t%s\t%s\t%s\n",
+				k.ID, k.Name, user, k.Prefix, k.Scopes, apiKeyState(&k, now),
+				k.CreatedAt.Format("2006-01-02 15:04"), formatOptionalTime(k.ExpiresAt), formatOptionalTime(k.LastUsedAt))
+		}
+		w.Flush()
+	},
+}
+
+// APIKeyRevokeCmd représente la commande 'apikey revoke'
+var APIKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Révoque une clé d'API.",
+	Long: `Cette commande révoque une clé d'API : elle est refusée dès la requête suivante.
+La clé reste visible dans 'apikey list' pour l'historique.
+
+Exemple:
+  url-shortener apikey revoke --id=3`,
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetUint("id")
+		if id == 0 {
+			log.Println("Erreur: le flag --id est requis")
+			os.Exit(1)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		apiKeyService := services.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+
+		key, err := apiKeyService.RevokeKey(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("Erreur: aucune clé d'API avec l'ID %d", id)
+				os.Exit(1)
+			}
+			if errors.Is(err, services.ErrAPIKeyRevoked) {
+				log.Printf("Erreur: la clé d'API %d est déjà révoquée", id)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la révocation de la clé d'API: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Clé d'API %d (%s, %s…) révoquée.\n", key.ID, key.Name, key.Prefix)
+	},
+}
+
+// apiKeyState retourne l'état lisible d'une clé d'API.
+func apiKeyState(key *models.APIKey, now time.Time) string {
+	switch {
+	case key.RevokedAt != nil:
+		return "révoquée"
+	case key.IsExpired(now):
+		return "expirée"
+	default:
+		return "active"
+	}
+}
+
+// formatOptionalTime formate une date optionnelle pour un tableau ("-" si absente).
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func init() {
+	APIKeyCreateCmd.Flags().StringP("name", "n", "", "Nom de la clé (service ou personne qui l'utilise)")
+	APIKeyCreateCmd.Flags().String("user", "", "Utilisateur au nom duquel la clé agit (voir 'url-shortener user list')")
+	APIKeyCreateCmd.Flags().StringSlice("scopes", nil, "Scopes accordés, séparés par des virgules: "+strings.Join(models.AllScopes, ", "))
+	APIKeyCreateCmd.Flags().String("expires-at", "", "Date d'expiration de la clé (RFC 3339 ou AAAA-MM-JJ)")
+	APIKeyCreateCmd.Flags().Duration("expires-in", 0, "Durée de validité de la clé (ex: 720h)")
+	APIKeyCreateCmd.MarkFlagRequired("name")
+	APIKeyCreateCmd.MarkFlagRequired("scopes")
+
+	APIKeyRevokeCmd.Flags().Uint("id", 0, "ID de la clé à révoquer (voir 'apikey list')")
+	APIKeyRevokeCmd.MarkFlagRequired("id")
+
+	APIKeyCmd.AddCommand(APIKeyCreateCmd, APIKeyListCmd, APIKeyRevokeCmd)
+	cmd2.RootCmd.AddCommand(APIKeyCmd)
+}
@@ -1,18 +1,16 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 // CreateCmd représente la commande 'create'
@@ -22,10 +20,19 @@ var CreateCmd = &cobra.Command{
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
 Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://www.example.com/soldes" --alias="spring-sale"
+  url-shortener create --url="https://www.example.com/promo" --expires-at="2025-12-31T23:59:59Z" --max-clicks=100
+  url-shortener create --url="https://partenaire.example.com/offre" --fallback-url="https://www.example.com/offres"
+  url-shortener create --url="https://www.example.com/campagne" --owner="marketing"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Récupération du flag --url depuis Cobra
 		longURL, _ := cmd.Flags().GetString("url")
+		alias, _ := cmd.Flags().GetString("alias")
+		expiresAtFlag, _ := cmd.Flags().GetString("expires-at")
+		maxClicks, _ := cmd.Flags().GetInt("max-clicks")
+		fallbackURL, _ := cmd.Flags().GetString("fallback-url")
+		owner, _ := cmd.Flags().GetString("owner")
 
 		// Valider que le flag --url a été fourni
 		if longURL == "" {
@@ -33,45 +40,78 @@ Exemple:
 			os.Exit(1)
 		}
 
-		// Valider que l'URL est bien formée
-		_, err := url.Parse(longURL)
-		if err != nil {
-			log.Printf("Erreur: URL invalide: %v", err)
-			os.Exit(1)
-		}
-
 		// Charger la configuration chargée globalement via cmd.cfg
 		cfg := cmd2.Cfg
 		if cfg == nil {
 			log.Fatalf("Configuration not loaded")
 		}
 
-		// Initialiser la connexion à la BDD
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+		// Valider les URLs avec la même politique que l'API (schéma, longueur, domaine, réseaux privés)
+		policy := urlPolicy()
+		if err := policy.Check(longURL); err != nil {
+			log.Printf("Erreur: URL refusée: %v", err)
+			os.Exit(1)
+		}
+		if fallbackURL != "" {
+			if err := policy.Check(fallbackURL); err != nil {
+				log.Printf("Erreur: URL de repli refusée: %v", err)
+				os.Exit(1)
+			}
 		}
 
-		// Auto-migrate the schema
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
-		if err != nil {
-			log.Fatalf("Failed to migrate database: %v", err)
+		// Valider l'alias avant d'ouvrir la base de données pour un retour immédiat
+		if alias != "" {
+			if err := services.ValidateAlias(alias); err != nil {
+				log.Printf("Erreur: alias invalide: %v", err)
+				os.Exit(1)
+			}
 		}
 
-		// S'assurer que la connexion est fermée à la fin
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		// Interpréter la date d'expiration optionnelle
+		var expiresAt *time.Time
+		if expiresAtFlag != "" {
+			t, err := time.Parse(time.RFC3339, expiresAtFlag)
+			if err != nil {
+				log.Printf("Erreur: date d'expiration invalide (format attendu RFC 3339, ex: 2025-12-31T23:59:59Z): %v", err)
+				os.Exit(1)
+			}
+			expiresAt = &t
 		}
-		defer sqlDB.Close()
+
+		if maxClicks < 0 {
+			log.Println("Erreur: --max-clicks doit être positif")
+			os.Exit(1)
+		}
+
+		// Initialiser la connexion à la BDD (migrations comprises), fermée à la fin de la commande
+		db, closeDB := openDatabase()
+		defer closeDB()
 
 		// Initialiser les repositories et services nécessaires
-		linkRepo := repository.NewLinkRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		linkService := services.NewLinkService(repository.NewLinkRepository(db), policy, destinationBlocklist())
+
+		// La CLI voit tous les liens ; --owner attribue le lien créé à un utilisateur
+		principal := services.SystemPrincipal
+		if owner != "" {
+			principal.UserID = &lookupUser(db, owner).ID
+		}
 
 		// Créer le lien court
-		link, err := linkService.CreateLink(longURL)
+		link, err := linkService.CreateLink(principal, longURL, services.CreateLinkOptions{
+			Alias:       alias,
+			ExpiresAt:   expiresAt,
+			MaxClicks:   maxClicks,
+			FallbackURL: fallbackURL,
+		})
 		if err != nil {
+			if errors.Is(err, services.ErrAliasTaken) {
+				log.Printf("Erreur: l'alias '%s' est déjà utilisé par un autre lien", alias)
+				os.Exit(1)
+			}
+			if errors.Is(err, services.ErrURLBlocked) {
+				log.Printf("Erreur: destination refusée, elle figure dans une liste de blocage: %v", err)
+				os.Exit(1)
+			}
 			log.Printf("Erreur lors de la création du lien: %v", err)
 			os.Exit(1)
 		}
@@ -83,12 +123,33 @@ Exemple:
 		fmt.Printf("URL longue: %s\n", link.LongURL)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
 		fmt.Printf("Date de création: %s\n", link.CreatedAt.Format("2006-01-02 15:04:05"))
+		if link.ExpiresAt != nil {
+			fmt.Printf("Date d'expiration: %s\n", link.ExpiresAt.Format("2006-01-02 15:04:05"))
+		}
+		if link.MaxClicks > 0 {
+			fmt.Printf("Nombre maximal de clics: %d\n", link.MaxClicks)
+		}
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de repli: %s\n", link.FallbackURL)
+		}
+		if owner != "" {
+			fmt.Printf("Propriétaire: %s\n", owner)
+		}
 	},
 }
 
 func init() {
 	// Définir le flag --url pour la commande create
 	CreateCmd.Flags().StringP("url", "u", "", "URL longue à raccourcir")
+	// Définir le flag optionnel --alias pour choisir son propre code court
+	CreateCmd.Flags().StringP("alias", "a", "", "Alias personnalisé à utiliser comme code court (ex: spring-sale)")
+	// Définir les flags optionnels d'expiration du lien
+	CreateCmd.Flags().String("expires-at", "", "Date d'expiration du lien au format RFC 3339 (ex: 2025-12-31T23:59:59Z)")
+	CreateCmd.Flags().Int("max-clicks", 0, "Nombre maximal de redirections autorisées (0 = illimité)")
+	// Définir le flag optionnel de l'URL de repli, servie lorsque le moniteur signale l'URL longue en panne
+	CreateCmd.Flags().String("fallback-url", "", "URL de repli servie tant que l'URL longue est en panne")
+	// Définir le flag optionnel du propriétaire, seul à voir le lien via l'API (avec les administrateurs)
+	CreateCmd.Flags().String("owner", "", "Utilisateur propriétaire du lien (voir 'url-shortener user list')")
 
 	// Marquer le flag comme requis
 	CreateCmd.MarkFlagRequired("url")
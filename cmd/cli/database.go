@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+	"log"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/blocklist"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
+	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
+	"gorm.io/gorm"
+)
+
+// openDatabase ouvre la base de données configurée, applique les migrations
+// et retourne la connexion ainsi qu'une fonction de fermeture à appeler avec defer.
+// Le programme s'arrête si la configuration n'est pas chargée ou si la connexion échoue.
+func openDatabase() (*gorm.DB, func()) {
+	cfg := cmd2.Cfg
+	if cfg == nil {
+		log.Fatalf("Configuration not loaded")
+	}
+
+	db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if err := repository.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+
+	return db, func() { sqlDB.Close() }
+}
+
+// urlPolicy retourne la politique des URLs de destination configurée.
+// Le programme s'arrête si elle est invalide.
+func urlPolicy() *urlpolicy.Policy {
+	policy, err := cmd2.NewURLPolicy()
+	if err != nil {
+		log.Fatalf("Invalid url policy configuration: %v", err)
+	}
+	return policy
+}
+
+// destinationBlocklist retourne les listes de blocage configurées (nil si aucune).
+// Le programme s'arrête si l'un des fichiers est illisible.
+func destinationBlocklist() *blocklist.Blocklist {
+	list, err := cmd2.NewBlocklist()
+	if err != nil {
+		log.Fatalf("Failed to load blocklist: %v", err)
+	}
+	return list
+}
+
+// lookupUser retourne l'utilisateur nommé name. Le programme s'arrête s'il n'existe pas.
+func lookupUser(db *gorm.DB, name string) *models.User {
+	user, err := services.NewUserService(repository.NewUserRepository(db)).GetUserByName(name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Fatalf("Erreur: aucun utilisateur nommé '%s' (voir 'url-shortener user list')", name)
+		}
+		log.Fatalf("Erreur lors de la recherche de l'utilisateur: %v", err)
+	}
+	return user
+}
+
+// newLinkService crée le LinkService des commandes, avec la politique des URLs et les listes de blocage configurées.
+func newLinkService(db *gorm.DB) *services.LinkService {
+	return services.NewLinkService(repository.NewLinkRepository(db), urlPolicy(), destinationBlocklist())
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// DeleteCmd représente la commande 'delete'
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime (logiquement) un lien court.",
+	Long: `Cette commande supprime logiquement un lien : il ne redirige plus,
+mais son historique de clics est conservé et il peut être restauré avec 'restore'.
+
+Exemple:
+  url-shortener delete --code="spring-sale"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		if shortCode == "" {
+			log.Println("Erreur: le flag --code est requis")
+			os.Exit(1)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		if err := linkService.DeleteLink(services.SystemPrincipal, shortCode); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("Erreur: aucun lien actif avec le code '%s'", shortCode)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la suppression du lien: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien '%s' supprimé. Utilisez 'url-shortener restore --code=%s' pour l'annuler.\n", shortCode, shortCode)
+	},
+}
+
+func init() {
+	DeleteCmd.Flags().StringP("code", "c", "", "Code court du lien à supprimer")
+
+	DeleteCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(DeleteCmd)
+}
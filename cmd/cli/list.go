@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// listedLink est la représentation JSON d'un lien dans la sortie de 'list --output=json'.
+type listedLink struct {
+	ShortCode   string     `json:"short_code"`
+	LongURL     string     `json:"long_url"`
+	TotalClicks int        `json:"total_clicks"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Disabled    bool       `json:"disabled,omitempty"`
+}
+
+// ListCmd représente la commande 'list'
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts existants.",
+	Long: `Cette commande affiche les liens existants, page par page, sous forme de tableau ou de JSON.
+Utilisez la valeur 'Page suivante' affichée en fin de tableau avec --cursor pour continuer.
+
+Exemples:
+  url-shortener list
+  url-shortener list --sort=clicks --limit=10
+  url-shortener list --domain=example.com --created-after=2025-01-01 --output=json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		cursor, _ := cmd.Flags().GetString("cursor")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		order, _ := cmd.Flags().GetString("order")
+		domain, _ := cmd.Flags().GetString("domain")
+		createdAfterFlag, _ := cmd.Flags().GetString("created-after")
+		output, _ := cmd.Flags().GetString("output")
+
+		if output != "table" && output != "json" {
+			log.Println("Erreur: --output doit valoir 'table' ou 'json'")
+			os.Exit(1)
+		}
+
+		params := services.ListLinksParams{
+			Limit:  limit,
+			Cursor: cursor,
+			SortBy: sortBy,
+			Order:  order,
+			Domain: domain,
+		}
+		if createdAfterFlag != "" {
+			t, err := parseDateFlag(createdAfterFlag, time.Local)
+			if err != nil {
+				log.Printf("Erreur: --created-after invalide: %v", err)
+				os.Exit(1)
+			}
+			params.CreatedAfter = &t
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		page, err := linkService.ListLinks(services.SystemPrincipal, params)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidListParams) {
+				log.Printf("Erreur: %v", err)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la récupération des liens: %v", err)
+			os.Exit(1)
+		}
+
+		if output == "json" {
+			links := make([]listedLink, 0, len(page.Links))
+			for _, l := range page.Links {
+				links = append(links, listedLink{
+					ShortCode:   l.Shortcode,
+					LongURL:     l.LongURL,
+					TotalClicks: l.ClickCount,
+					CreatedAt:   l.CreatedAt,
+					ExpiresAt:   l.ExpiresAt,
+					Disabled:    l.Disabled,
+				})
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(struct {
+				Links      []listedLink `json:"links"`
+				NextCursor string       `json:"next_cursor,omitempty"`
+			}{links, page.NextCursor})
+			return
+		}
+
+		if len(page.Links) == 0 {
+			fmt.Println("Aucun lien trouvé.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tCLICS\tCRÉÉ LE\tÉTAT\tURL LONGUE")
+		for _, l := range page.Links {
+			state := "actif"
+			if l.Disabled {
+				state = "désactivé"
+			} else if l.IsExpired(time.Now()) {
+				state = "expiré"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+				l.Shortcode, l.ClickCount, l.CreatedAt.Format("2006-01-02 15:04"), state, l.LongURL)
+		}
+		w.Flush()
+
+		if page.NextCursor != "" {
+			fmt.Printf("\nPage suivante: --cursor=%s\n", page.NextCursor)
+		}
+	},
+}
+
+// parseDateFlag interprète une date passée en flag, au format RFC 3339 ou AAAA-MM-JJ (minuit dans le fuseau loc).
+func parseDateFlag(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+func init() {
+	ListCmd.Flags().IntP("limit", "l", services.DefaultListLimit, "Nombre de liens par page")
+	ListCmd.Flags().String("cursor", "", "Curseur de la page à afficher (fourni par la page précédente)")
+	ListCmd.Flags().String("sort", "created_at", "Critère de tri: created_at ou clicks")
+	ListCmd.Flags().String("order", "desc", "Ordre de tri: asc ou desc")
+	ListCmd.Flags().String("domain", "", "Ne liste que les liens dont le domaine de destination contient cette valeur")
+	ListCmd.Flags().String("created-after", "", "Ne liste que les liens créés après cette date (RFC 3339 ou AAAA-MM-JJ)")
+	ListCmd.Flags().StringP("output", "o", "table", "Format de sortie: table ou json")
+
+	cmd2.RootCmd.AddCommand(ListCmd)
+}
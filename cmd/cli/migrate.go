@@ -5,7 +5,7 @@ import (
 	"log"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/spf13/cobra"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -17,7 +17,7 @@ var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
 	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
+et exécute les migrations automatiques de GORM pour créer les tables 'links', 'clicks' et 'click_rollups'
 basées sur les modèles Go.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Charger la configuration chargée globalement via cmd.cfg
@@ -39,9 +39,9 @@ basées sur les modèles Go.`,
 		// Assurez-vous que la connexion est fermée après la migration grâce à defer
 		defer sqlDB.Close()
 
-		// Exécuter les migrations automatiques de GORM.
-		// Utilisez db.AutoMigrate() et passez-lui les pointeurs vers tous vos modèles.
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
+		// Exécuter les migrations automatiques de GORM sur tous les modèles,
+		// ainsi que les reprises de données qu'elles nécessitent (voir repository.Migrate).
+		err = repository.Migrate(db)
 		if err != nil {
 			log.Fatalf("Failed to migrate database: %v", err)
 		}
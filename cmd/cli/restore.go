@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// RestoreCmd représente la commande 'restore'
+var RestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restaure un lien court supprimé.",
+	Long: `Cette commande annule la suppression logique d'un lien, qui redirige à nouveau.
+
+Exemple:
+  url-shortener restore --code="spring-sale"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		if shortCode == "" {
+			log.Println("Erreur: le flag --code est requis")
+			os.Exit(1)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		link, err := linkService.RestoreLink(services.SystemPrincipal, shortCode)
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				log.Printf("Erreur: aucun lien avec le code '%s'", shortCode)
+			case errors.Is(err, services.ErrLinkNotDeleted):
+				log.Printf("Erreur: le lien '%s' n'est pas supprimé", shortCode)
+			default:
+				log.Printf("Erreur lors de la restauration du lien: %v", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien restauré avec succès:\n")
+		fmt.Printf("Code court: %s\n", link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+	},
+}
+
+func init() {
+	RestoreCmd.Flags().StringP("code", "c", "", "Code court du lien à restaurer")
+
+	RestoreCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(RestoreCmd)
+}
@@ -4,14 +4,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
 // StatsCmd représente la commande 'stats'
@@ -19,10 +18,12 @@ var StatsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Affiche les statistiques d'un lien court",
 	Long: `Cette commande affiche les statistiques détaillées pour un code court donné,
-incluant l'URL longue associée et le nombre total de clics.
+incluant l'URL longue associée, le nombre total de clics, les principaux référents
+et l'évolution des clics sur la période choisie (30 derniers jours par défaut).
 
 Exemple d'utilisation:
-  url-shortener stats --code abc123`,
+  url-shortener stats --code abc123
+  url-shortener stats --code abc123 --from=2025-03-01 --to=2025-04-01 --interval=week --tz=Europe/Paris`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Récupération du flag --code depuis Cobra.
 		shortCodeFlag, _ := cmd.Flags().GetString("code")
@@ -32,41 +33,330 @@ Exemple d'utilisation:
 			os.Exit(1)
 		}
 
-		cfg := cmd2.Cfg
-		if cfg == nil {
-			log.Fatalf("Configuration not loaded")
+		// Période et granularité des statistiques détaillées
+		interval, _ := cmd.Flags().GetString("interval")
+		window, loc, err := parseStatsWindowFlags(cmd)
+		if err != nil {
+			log.Printf("Erreur: %v", err)
+			os.Exit(1)
 		}
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+		clickService := services.NewClickService(repository.NewClickRepository(db), cmd2.Cfg.Analytics.RetentionDays)
+		healthService := services.NewHealthService(repository.NewLinkCheckRepository(db))
+
+		link, totalClicks, err := linkService.GetLinkStats(services.SystemPrincipal, shortCodeFlag, window.IncludeBots)
+		if err != nil {
+			log.Printf("Erreur lors de la récupération des statistiques: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Statistiques pour le code court: %s\n", link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		fmt.Printf("Date de création: %s\n", link.CreatedAt.Format("2006-01-02 15:04:05"))
+		if window.IncludeBots {
+			fmt.Printf("Nombre total de clics (robots inclus): %d\n", totalClicks)
+		} else {
+			fmt.Printf("Nombre total de clics: %d\n", totalClicks)
+		}
+		if link.ExpiresAt != nil {
+			fmt.Printf("Date d'expiration: %s\n", link.ExpiresAt.Format("2006-01-02 15:04:05"))
+		}
+		if link.MaxClicks > 0 {
+			fmt.Printf("Budget de clics: %d/%d consommé(s)\n", link.UsedClicks, link.MaxClicks)
+		}
+		if link.Disabled {
+			fmt.Println("État: désactivé")
+		}
+		if link.DeletedAt.Valid {
+			fmt.Printf("État: supprimé le %s\n", link.DeletedAt.Time.Format("2006-01-02 15:04:05"))
+		}
+		if link.Quarantined {
+			fmt.Printf("État: en quarantaine (liste de blocage: %s)\n", link.QuarantineReason)
+		}
+
+		fmt.Printf("\nPériode: du %s au %s (%s)\n",
+			window.From.In(loc).Format("2006-01-02 15:04"), window.To.In(loc).Format("2006-01-02 15:04"), loc)
+
+		// Évolution des clics sur la période
+		buckets, err := clickService.GetClickTimeSeries(link.ID, window, interval, loc)
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			log.Printf("Erreur lors de la récupération de l'évolution des clics: %v", err)
+			os.Exit(1)
+		}
+		printTimeSeries(buckets, interval)
+
+		// Les répartitions ne portent que sur les clics bruts, supprimés au-delà de la durée de rétention
+		if breakdownWindow, truncated := clickService.BreakdownWindow(window); truncated {
+			fmt.Printf("\nRépartitions limitées aux clics conservés depuis le %s (analytics.retention_days)\n",
+				breakdownWindow.From.In(loc).Format("2006-01-02 15:04"))
 		}
 
-		sqlDB, err := db.DB()
+		// Principaux domaines référents sur la période
+		referrers, err := clickService.GetTopReferrers(link.ID, window, 10)
 		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			log.Printf("Erreur lors de la récupération des référents: %v", err)
+			os.Exit(1)
+		}
+		fmt.Println("\nPrincipaux référents:")
+		if len(referrers) == 0 {
+			fmt.Println("  Aucun clic sur la période.")
+		}
+		for _, r := range referrers {
+			fmt.Printf("  %-40s %d\n", r.Domain, r.Clicks)
 		}
 
-		defer sqlDB.Close()
+		// Répartition par navigateur, système et type d'appareil
+		breakdown, err := clickService.GetDeviceBreakdown(link.ID, window)
+		if err != nil {
+			log.Printf("Erreur lors de la récupération de la répartition par appareil: %v", err)
+			os.Exit(1)
+		}
+		printBreakdown("Navigateurs", breakdown.Browsers)
+		printBreakdown("Systèmes d'exploitation", breakdown.OS)
+		printBreakdown("Types d'appareils", breakdown.DeviceTypes)
+		// Répartition géographique (nécessite une base GeoIP configurée côté serveur)
+		geo, err := clickService.GetGeoBreakdown(link.ID, window)
+		if err != nil {
+			log.Printf("Erreur lors de la récupération de la répartition géographique: %v", err)
+			os.Exit(1)
+		}
+		printBreakdown("Pays", geo.Countries)
 
-		linkRepo := repository.NewLinkRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		if window.IncludeBots {
+			fmt.Printf("\nDont clics de robots: %d\n", breakdown.BotClicks)
+		} else {
+			fmt.Printf("\nClics de robots exclus: %d (utilisez --include-bots pour les compter)\n", breakdown.BotClicks)
+		}
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		// État de l'URL longue d'après l'historique du moniteur
+		health, err := healthService.GetLinkHealth(link.ID, window.From, window.To, 5)
 		if err != nil {
-			log.Printf("Erreur lors de la récupération des statistiques: %v", err)
+			log.Printf("Erreur lors de la récupération de l'état de l'URL longue: %v", err)
 			os.Exit(1)
 		}
+		printHealth(health, loc)
 
-		fmt.Printf("Statistiques pour le code court: %s\n", link.Shortcode)
-		fmt.Printf("URL longue: %s\n", link.LongURL)
-		fmt.Printf("Date de création: %s\n", link.CreatedAt.Format("2006-01-02 15:04:05"))
-		fmt.Printf("Nombre total de clics: %d\n", totalClicks)
+		// Routage des redirections (URL de repli) et dernières bascules décidées par le moniteur
+		events, err := linkService.GetRoutingEvents(services.SystemPrincipal, shortCodeFlag, 5)
+		if err != nil {
+			log.Printf("Erreur lors de la récupération des bascules de routage: %v", err)
+			os.Exit(1)
+		}
+		if link.FallbackURL != "" || len(events) > 0 {
+			printRouting(link, events, loc)
+		}
 	},
 }
 
+// printBreakdown affiche une répartition des clics sous forme de liste alignée.
+func printBreakdown(title string, counts []repository.DimensionCount) {
+	fmt.Printf("\n%s:\n", title)
+	if len(counts) == 0 {
+		fmt.Println("  Aucun clic sur la période.")
+	}
+	for _, count := range counts {
+		fmt.Printf("  %-40s %d\n", count.Value, count.Clicks)
+	}
+}
+
+// printHealth affiche l'état de l'URL longue, sa disponibilité sur la période et ses dernières vérifications.
+func printHealth(health *services.LinkHealth, loc *time.Location) {
+	fmt.Println("\nÉtat de l'URL longue:")
+	if health.LastCheck == nil {
+		fmt.Println("  Jamais vérifiée par le moniteur.")
+		return
+	}
+
+	fmt.Printf("  État actuel: %s (vérifiée le %s)\n",
+		healthLabel(health.State), health.LastCheck.CheckedAt.In(loc).Format("2006-01-02 15:04:05"))
+	if health.UptimePercent != nil {
+		fmt.Printf("  Disponibilité sur la période: %.1f%% (%d vérification(s))\n", *health.UptimePercent, health.Checks)
+	} else {
+		fmt.Println("  Disponibilité sur la période: aucune vérification.")
+	}
+
+	fmt.Println("  Dernières vérifications:")
+	for _, check := range health.Recent {
+		result := "-"
+		if check.StatusCode != 0 {
+			result = fmt.Sprintf("HTTP %d", check.StatusCode)
+		}
+		if check.ErrorClass != "" && check.ErrorClass != models.CheckErrorHTTPStatus {
+			result = check.ErrorClass
+		}
+		fmt.Printf("    %s  %-12s %-12s %d ms\n",
+			check.CheckedAt.In(loc).Format("2006-01-02 15:04:05"), healthLabel(check.State()), result, check.LatencyMs)
+		if check.FinalURL != "" {
+			fmt.Printf("      %d redirection(s) vers %s\n", len(check.RedirectChain), check.FinalURL)
+		}
+	}
+}
+
+// printRouting affiche le routage en vigueur d'un lien et ses dernières bascules.
+func printRouting(link *models.Link, events []models.LinkRoutingEvent, loc *time.Location) {
+	fmt.Println("\nRoutage des redirections:")
+	if link.FallbackURL != "" {
+		fmt.Printf("  URL de repli: %s\n", link.FallbackURL)
+	}
+	if link.Routing() == models.RoutingFallback {
+		since := ""
+		if link.FailedOverAt != nil {
+			since = " depuis le " + link.FailedOverAt.In(loc).Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("  Routage actuel: %s%s\n", routingLabel(models.RoutingFallback), since)
+	} else {
+		fmt.Printf("  Routage actuel: %s\n", routingLabel(models.RoutingPrimary))
+	}
+
+	if len(events) == 0 {
+		return
+	}
+	fmt.Println("  Dernières bascules:")
+	for _, event := range events {
+		fmt.Printf("    %s  vers %s", event.OccurredAt.In(loc).Format("2006-01-02 15:04:05"), routingLabel(event.Routing))
+		if event.ConsecutiveFailures > 0 {
+			fmt.Printf(" après %d échec(s) consécutif(s)", event.ConsecutiveFailures)
+		}
+		fmt.Println()
+	}
+}
+
+// routingLabel traduit un routage pour l'affichage.
+func routingLabel(routing string) string {
+	if routing == models.RoutingFallback {
+		return "URL de repli"
+	}
+	return "URL longue"
+}
+
+// healthLabel traduit un état de santé pour l'affichage.
+func healthLabel(state string) string {
+	switch state {
+	case services.HealthUp:
+		return "accessible"
+	case services.HealthDown:
+		return "inaccessible"
+	case services.HealthChanged:
+		return "déplacée"
+	default:
+		return "inconnu"
+	}
+}
+
+// parseStatsWindowFlags lit les flags --from, --to, --tz et --include-bots de la commande stats.
+// Par défaut, la période couvre les 30 derniers jours dans le fuseau local.
+func parseStatsWindowFlags(cmd *cobra.Command) (repository.ClickFilter, *time.Location, error) {
+	fromFlag, _ := cmd.Flags().GetString("from")
+	toFlag, _ := cmd.Flags().GetString("to")
+	tz, _ := cmd.Flags().GetString("tz")
+	includeBots, _ := cmd.Flags().GetBool("include-bots")
+
+	loc := time.Local
+	if tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return repository.ClickFilter{}, nil, fmt.Errorf("fuseau horaire inconnu '%s'", tz)
+		}
+		loc = l
+	}
+
+	window := repository.ClickFilter{To: time.Now(), IncludeBots: includeBots}
+	if toFlag != "" {
+		t, err := parseDateFlag(toFlag, loc)
+		if err != nil {
+			return window, nil, fmt.Errorf("--to invalide: %w", err)
+		}
+		window.To = t
+	}
+
+	window.From = window.To.AddDate(0, 0, -30)
+	if fromFlag != "" {
+		t, err := parseDateFlag(fromFlag, loc)
+		if err != nil {
+			return window, nil, fmt.Errorf("--from invalide: %w", err)
+		}
+		window.From = t
+	}
+
+	if !window.From.Before(window.To) {
+		return window, nil, fmt.Errorf("--from doit être antérieur à --to")
+	}
+	return window, loc, nil
+}
+
+// sparklineLevels est l'échelle de caractères ASCII utilisée par sparkline, du plus bas au plus haut.
+const sparklineLevels = "_.-~=+*#"
+
+// sparkline représente une série de valeurs sur une ligne, un caractère par valeur.
+// Les valeurs nulles utilisent le premier niveau, la valeur maximale le dernier.
+func sparkline(values []int) string {
+	maxValue := 0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+
+	line := make([]byte, len(values))
+	for i, v := range values {
+		level := 0
+		if maxValue > 0 && v > 0 {
+			level = 1 + (v*(len(sparklineLevels)-2))/maxValue
+		}
+		line[i] = sparklineLevels[level]
+	}
+	return string(line)
+}
+
+// printTimeSeries affiche l'évolution des clics sous forme de sparkline, suivie du détail non nul.
+func printTimeSeries(buckets []services.TimeBucket, interval string) {
+	layout := "2006-01-02"
+	if interval == services.IntervalHour {
+		layout = "2006-01-02 15:04"
+	}
+
+	values := make([]int, len(buckets))
+	total, peak := 0, 0
+	for i, b := range buckets {
+		values[i] = b.Clicks
+		total += b.Clicks
+		if b.Clicks > buckets[peak].Clicks {
+			peak = i
+		}
+	}
+
+	fmt.Printf("\nÉvolution des clics (par %s):\n", intervalLabel(interval))
+	if total == 0 {
+		fmt.Println("  Aucun clic sur la période.")
+		return
+	}
+	fmt.Printf("  %s\n", sparkline(values))
+	fmt.Printf("  %s → %s, pic de %d clic(s) le %s\n",
+		buckets[0].Start.Format(layout), buckets[len(buckets)-1].Start.Format(layout),
+		buckets[peak].Clicks, buckets[peak].Start.Format(layout))
+}
+
+// intervalLabel traduit une granularité pour l'affichage.
+func intervalLabel(interval string) string {
+	switch interval {
+	case services.IntervalHour:
+		return "heure"
+	case services.IntervalWeek:
+		return "semaine"
+	default:
+		return "jour"
+	}
+}
+
 func init() {
 	StatsCmd.Flags().StringP("code", "c", "", "Code court pour lequel afficher les statistiques")
+	StatsCmd.Flags().String("from", "", "Début de la période (RFC 3339 ou AAAA-MM-JJ, 30 jours avant --to par défaut)")
+	StatsCmd.Flags().String("to", "", "Fin de la période (RFC 3339 ou AAAA-MM-JJ, maintenant par défaut)")
+	StatsCmd.Flags().String("interval", services.IntervalDay, "Granularité de l'évolution des clics: hour, day ou week")
+	StatsCmd.Flags().Bool("include-bots", false, "Compte aussi les clics de robots et d'aperçus de liens")
+	StatsCmd.Flags().String("tz", "", "Fuseau horaire des intervalles (ex: Europe/Paris, fuseau local par défaut)")
 
 	StatsCmd.MarkFlagRequired("code")
 
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// UpdateCmd représente la commande 'update'
+var UpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Modifie la destination ou l'état d'un lien court.",
+	Long: `Cette commande change l'URL de destination ou l'URL de repli d'un lien existant,
+ou le désactive / réactive. Un lien désactivé ne redirige plus mais conserve ses statistiques.
+
+Exemples:
+  url-shortener update --code="spring-sale" --url="https://www.example.com/nouvelle-page"
+  url-shortener update --code="spring-sale" --disable
+  url-shortener update --code="spring-sale" --enable
+  url-shortener update --code="spring-sale" --fallback-url="https://www.example.com/offres"
+  url-shortener update --code="spring-sale" --fallback-url=""
+  url-shortener update --code="spring-sale" --owner="marketing"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		longURL, _ := cmd.Flags().GetString("url")
+		disable, _ := cmd.Flags().GetBool("disable")
+		enable, _ := cmd.Flags().GetBool("enable")
+		fallbackURL, _ := cmd.Flags().GetString("fallback-url")
+		owner, _ := cmd.Flags().GetString("owner")
+
+		if shortCode == "" {
+			log.Println("Erreur: le flag --code est requis")
+			os.Exit(1)
+		}
+		if disable && enable {
+			log.Println("Erreur: --disable et --enable sont incompatibles")
+			os.Exit(1)
+		}
+
+		var opts services.UpdateLinkOptions
+		if longURL != "" {
+			if _, err := url.ParseRequestURI(longURL); err != nil {
+				log.Printf("Erreur: URL invalide: %v", err)
+				os.Exit(1)
+			}
+			opts.LongURL = &longURL
+		}
+		if disable || enable {
+			opts.Disabled = &disable
+		}
+		// Une valeur vide retire l'URL de repli : seul l'usage du flag compte.
+		if cmd.Flags().Changed("fallback-url") {
+			if fallbackURL != "" {
+				if _, err := url.ParseRequestURI(fallbackURL); err != nil {
+					log.Printf("Erreur: URL de repli invalide: %v", err)
+					os.Exit(1)
+				}
+			}
+			opts.FallbackURL = &fallbackURL
+		}
+		if opts.LongURL == nil && opts.Disabled == nil && opts.FallbackURL == nil && owner == "" {
+			log.Println("Erreur: rien à modifier, précisez --url, --fallback-url, --owner, --disable ou --enable")
+			os.Exit(1)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		if owner != "" {
+			opts.OwnerID = &lookupUser(db, owner).ID
+		}
+
+		linkService := newLinkService(db)
+
+		link, err := linkService.UpdateLink(services.SystemPrincipal, shortCode, opts)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("Erreur: aucun lien actif avec le code '%s'", shortCode)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la modification du lien: %v", err)
+			os.Exit(1)
+		}
+
+		state := "actif"
+		if link.Disabled {
+			state = "désactivé"
+		}
+		fmt.Printf("Lien mis à jour avec succès:\n")
+		fmt.Printf("Code court: %s\n", link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		fmt.Printf("État: %s\n", state)
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de repli: %s\n", link.FallbackURL)
+		}
+		if owner != "" {
+			fmt.Printf("Propriétaire: %s\n", owner)
+		}
+	},
+}
+
+func init() {
+	UpdateCmd.Flags().StringP("code", "c", "", "Code court du lien à modifier")
+	UpdateCmd.Flags().StringP("url", "u", "", "Nouvelle URL de destination")
+	UpdateCmd.Flags().Bool("disable", false, "Désactive le lien (il ne redirige plus)")
+	UpdateCmd.Flags().Bool("enable", false, "Réactive un lien désactivé")
+	UpdateCmd.Flags().String("fallback-url", "", "Nouvelle URL de repli, servie tant que l'URL longue est en panne (vide pour la retirer)")
+
+	UpdateCmd.Flags().String("owner", "", "Transfère le lien à cet utilisateur (voir 'url-shortener user list')")
+
+	UpdateCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(UpdateCmd)
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// UserCmd regroupe les commandes de gestion des utilisateurs ('user create|list').
+var UserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Gère les utilisateurs propriétaires des liens.",
+	Long: `Chaque lien créé via l'API appartient à l'utilisateur de la clé d'API utilisée.
+Via l'API, un utilisateur ne liste, ne modifie et ne consulte les statistiques que de ses propres liens ;
+les liens des autres répondent 404. Les clés portant le scope admin voient tous les liens.
+
+Rattachez une clé à un utilisateur avec 'apikey create --user', et attribuez un lien
+existant avec 'update --owner'.`,
+}
+
+// UserCreateCmd représente la commande 'user create'
+var UserCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Crée un utilisateur.",
+	Long: `Exemple:
+  url-shortener user create --name="marketing"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		userService := services.NewUserService(repository.NewUserRepository(db))
+
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidUser) || errors.Is(err, services.ErrUserExists) {
+				log.Printf("Erreur: %v", err)
+				os.Exit(1)
+			}
+			log.Printf("Erreur lors de la création de l'utilisateur: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Utilisateur '%s' créé (ID %d).\n", user.Name, user.ID)
+		fmt.Printf("Créez-lui une clé avec 'url-shortener apikey create --user=%s --name=... --scopes=...'.\n", user.Name)
+	},
+}
+
+// UserListCmd représente la commande 'user list'
+var UserListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les utilisateurs.",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		userService := services.NewUserService(repository.NewUserRepository(db))
+
+		users, err := userService.ListUsers()
+		if err != nil {
+			log.Printf("Erreur lors de la récupération des utilisateurs: %v", err)
+			os.Exit(1)
+		}
+		if len(users) == 0 {
+			fmt.Println("Aucun utilisateur. Créez-en un avec 'url-shortener user create'.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNOM\tCRÉÉ LE")
+		for _, u := range users {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", u.ID, u.Name, u.CreatedAt.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	UserCreateCmd.Flags().StringP("name", "n", "", "Nom de l'utilisateur (personne ou équipe)")
+	UserCreateCmd.MarkFlagRequired("name")
+
+	UserCmd.AddCommand(UserCreateCmd, UserListCmd)
+	cmd2.RootCmd.AddCommand(UserCmd)
+}
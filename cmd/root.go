@@ -3,7 +3,9 @@ package cmd
 import (
 	"log"
 
+	"github.com/axellelanca/urlshortener/internal/blocklist"
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 	"github.com/spf13/cobra"
 )
 
@@ -64,3 +66,24 @@ func initConfig() {
 	}
 	// La configuration est maintenant disponible via la variable globale 'cmd.cfg'.
 }
+
+// NewURLPolicy construit la politique des URLs de destination à partir de la section url_policy de la configuration.
+// Le serveur et la CLI l'utilisent tous deux, pour appliquer les mêmes règles aux liens créés par l'API ou en ligne de commande.
+func NewURLPolicy() (*urlpolicy.Policy, error) {
+	settings := Cfg.URLPolicy
+	return urlpolicy.New(urlpolicy.Options{
+		AllowedSchemes:       settings.AllowedSchemes,
+		MaxLength:            settings.MaxLength,
+		AllowedDomains:       settings.AllowedDomains,
+		DeniedDomains:        settings.DeniedDomains,
+		AllowPrivateNetworks: settings.AllowPrivateNetworks,
+	})
+}
+
+// NewBlocklist charge les listes de blocage configurées (section blocklist), ou retourne nil si aucune ne l'est.
+func NewBlocklist() (*blocklist.Blocklist, error) {
+	if len(Cfg.Blocklist.Files) == 0 {
+		return nil, nil
+	}
+	return blocklist.New(Cfg.Blocklist.Files)
+}
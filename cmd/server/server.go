@@ -1,23 +1,34 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/analytics"
 	"github.com/axellelanca/urlshortener/internal/api"
-	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/blocklist"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/journal"
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/notify"
 	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/retention"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
 	"gorm.io/gorm"
@@ -33,55 +44,183 @@ puis lance le serveur HTTP.`,
 		// Gestion d'erreur et faire un fatalF
 		cfg := cmd2.Cfg
 		if cfg == nil {
-			log.Fatalf("Configuration not loaded")
+			fatal("Configuration not loaded")
 		}
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		// Logs structurés (texte ou JSON) pour le serveur, Gin et les tâches de fond.
+		if err := logging.Setup(os.Stderr, cfg.Logging.Level, cfg.Logging.Format); err != nil {
+			fatal("Invalid logging configuration", "error", err)
+		}
+		gin.DebugPrintFunc = func(format string, values ...interface{}) {
+			slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)), "component", "gin")
+		}
+		slog.Info("Configuration loaded",
+			"port", cfg.Server.Port, "database", cfg.Database.Name,
+			"analytics_buffer", cfg.Analytics.BufferSize, "analytics_workers", cfg.Analytics.WorkerCount,
+			"monitor_interval_minutes", cfg.Monitor.IntervalMinutes)
+
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{Logger: logging.NewGormLogger()})
 		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
+			fatal("Failed to connect to database", "error", err)
 		}
 
 		// Auto-migrate the schema
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
+		err = repository.Migrate(db)
 		if err != nil {
-			log.Fatalf("Failed to migrate database: %v", err)
+			fatal("Failed to migrate database", "error", err)
 		}
 
 		// Instances de GormLinkRepository et GormClickRepository.
 		linkRepo := repository.NewLinkRepository(db)
 		clickRepo := repository.NewClickRepository(db)
+		checkRepo := repository.NewLinkCheckRepository(db)
+		apiKeyRepo := repository.NewAPIKeyRepository(db)
 
 		// Laissez le log
-		log.Println("Repositories initialisés.")
+		slog.Info("Repositories initialized")
 
 		// Créez des instances de LinkService et ClickService, en leur passant les repositories nécessaires.
-		linkService := services.NewLinkService(linkRepo)
-		_ = services.NewClickService(clickRepo) // ClickService pour utilisation future
+		urlPolicy, err := cmd2.NewURLPolicy()
+		if err != nil {
+			fatal("Invalid url policy configuration", "error", err)
+		}
+		destinationBlocklist, err := cmd2.NewBlocklist()
+		if err != nil {
+			fatal("Failed to load blocklist", "error", err)
+		}
+		linkService := services.NewLinkService(linkRepo, urlPolicy, destinationBlocklist)
+		clickService := services.NewClickService(clickRepo, cfg.Analytics.RetentionDays)
+		healthService := services.NewHealthService(checkRepo)
+		apiKeyService := services.NewAPIKeyService(apiKeyRepo)
 
 		// Laissez le log
-		log.Println("Services métiers initialisés.")
+		slog.Info("Services initialized")
+
+		// Géolocalisation optionnelle des clics à partir d'une base .mmdb locale
+		var geoResolver analytics.GeoResolver
+		if path := cfg.GeoIP.DatabasePath; path != "" {
+			resolver, err := analytics.OpenGeoIPDatabase(path)
+			if err != nil {
+				slog.Warn("GeoIP lookup disabled", "error", err)
+			} else {
+				defer resolver.Close()
+				geoResolver = resolver
+				slog.Info("GeoIP database loaded", "path", path)
+			}
+		}
+
+		// Anonymisation des adresses IP avant leur persistance (RGPD)
+		anonymizer, err := analytics.NewAnonymizer(cfg.Analytics.IPMode, cfg.Analytics.IPHashSecret)
+		if err != nil {
+			fatal("Invalid analytics.ip_mode configuration", "error", err)
+		}
+		if anonymizer.Mode() == analytics.IPModeHash && cfg.Analytics.IPHashSecret == "" {
+			slog.Warn("analytics.ip_hash_secret is not set, IP hashes will change on every restart")
+		}
+		slog.Info("IP address retention mode configured", "ip_mode", anonymizer.Mode())
+		enricher := analytics.NewEnricher(geoResolver, anonymizer)
+
+		// Journal disque optionnel : les clics survivent à un arrêt brutal et à un channel plein
+		var clickJournal *journal.Journal
+		if cfg.Journal.Dir != "" {
+			clickJournal, err = journal.Open(journal.Options{
+				Dir:          cfg.Journal.Dir,
+				SegmentSize:  int64(cfg.Journal.SegmentSizeMB) << 20,
+				SyncInterval: time.Duration(cfg.Journal.SyncIntervalMs) * time.Millisecond,
+			})
+			if err != nil {
+				fatal("Failed to open click journal", "error", err)
+			}
+			slog.Info("Click journal opened", "dir", cfg.Journal.Dir)
+		}
+
+		// Métriques Prometheus du serveur, dans un registre propre à cette instance.
+		serverMetrics := metrics.New(prometheus.NewRegistry())
+
+		// Pipeline des clics : channel bufferisé, workers d'écriture par lots et journal optionnel
+		pipeline := workers.NewClickPipeline(clickRepo, enricher, workers.PipelineOptions{
+			WorkerCount: cfg.Analytics.WorkerCount,
+			BufferSize:  cfg.Analytics.BufferSize,
+			Batch: workers.BatchOptions{
+				Size:          cfg.Analytics.BatchSize,
+				FlushInterval: time.Duration(cfg.Analytics.FlushIntervalMs) * time.Millisecond,
+			},
+			Journal: clickJournal,
+		})
+		pipeline.Start()
+		if err := serverMetrics.RegisterClickPipeline(pipeline); err != nil {
+			slog.Warn("Failed to register click pipeline metrics", "error", err)
+		}
 
 		// Passez les services nécessaires aux fonctions de configuration des routes.
-		router := gin.Default()
-		api.SetupRoutes(router, linkService, cfg.Analytics.BufferSize)
+		// Les middlewares de gin.Default() sont remplacés par leurs équivalents structurés,
+		// précédés de l'attribution d'un identifiant à chaque requête.
+		router := gin.New()
+		router.Use(api.RequestID(), api.AccessLog(), api.Recovery())
+		api.SetupRoutes(router, linkService, clickService, healthService, apiKeyService, pipeline, serverMetrics)
 
-		// Le channel est maintenant initialisé dans handlers.go
-		// Start click workers
-		workerCount := 2 // Default worker count
-		workers.StartClickWorkers(workerCount, api.ClickEventsChannel, clickRepo, linkRepo)
+		// Contexte des tâches de fond (moniteur, analyse des listes de blocage, purge), annulé à l'arrêt.
+		// Le WaitGroup permet d'attendre qu'elles aient rendu la main avant de quitter.
+		bgCtx, cancelBackground := context.WithCancel(context.Background())
+		defer cancelBackground()
+		var background sync.WaitGroup
+		runInBackground := func(start func(context.Context)) {
+			background.Add(1)
+			go func() {
+				defer background.Done()
+				start(bgCtx)
+			}()
+		}
 
-		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
-			cfg.Analytics.BufferSize, workerCount)
+		// Canaux d'alerte du moniteur ; le dispatcher les appelle en arrière-plan.
+		notifiers, err := buildNotifiers(cfg)
+		if err != nil {
+			fatal("Invalid notification configuration", "error", err)
+		}
+		dispatcher := notify.NewDispatcher(serverMetrics, notifiers...)
 
 		// Utilisez l'intervalle configuré
 		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval) // Le moniteur a besoin du linkRepo et de l'interval
+		urlMonitor, err := monitor.NewUrlMonitor(linkRepo, checkRepo, monitor.Options{
+			Interval:           monitorInterval,
+			History:            time.Duration(cfg.Monitor.HistoryDays) * 24 * time.Hour,
+			Workers:            cfg.Monitor.Workers,
+			PerHostConcurrency: cfg.Monitor.PerHostConcurrency,
+			PerHostInterval:    time.Duration(cfg.Monitor.PerHostIntervalMs) * time.Millisecond,
+			Timeout:            time.Duration(cfg.Monitor.TimeoutSeconds) * time.Second,
+			Jitter:             cfg.Monitor.Jitter,
+			MaxRedirects:       cfg.Monitor.MaxRedirects,
+			Soft404Patterns:    cfg.Monitor.Soft404Patterns,
+			FailureThreshold:   cfg.Monitor.Notifications.FailureThreshold,
+			FailoverThreshold:  cfg.Monitor.FailoverThreshold,
+			// Le moniteur ne contacte que des adresses publiques, même après résolution DNS ou redirection.
+			AllowPrivateNetworks: cfg.URLPolicy.AllowPrivateNetworks,
+			Notifier:             dispatcher,
+			Metrics:              serverMetrics,
+		})
+		if err != nil {
+			fatal("Invalid monitor configuration", "error", err)
+		}
 
-		go urlMonitor.Start()
+		runInBackground(urlMonitor.Start)
 
-		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
+		slog.Info("URL monitor started", "interval", monitorInterval.String())
 
-		log.Println("Routes API configurées.")
+		// Réanalyse des liens existants avec les listes de blocage, rechargées lorsqu'elles changent.
+		if destinationBlocklist != nil {
+			scanner := blocklist.NewScanner(destinationBlocklist, linkRepo, serverMetrics,
+				time.Duration(cfg.Blocklist.ReloadIntervalSeconds)*time.Second,
+				time.Duration(cfg.Blocklist.ScanIntervalMinutes)*time.Minute)
+			runInBackground(scanner.Start)
+		}
+
+		// Politique de rétention des clics (désactivée si retention_days vaut 0)
+		if cfg.Analytics.RetentionDays > 0 {
+			purger := retention.NewPurger(clickRepo, cfg.Analytics.RetentionDays, cfg.Analytics.PurgeBatchSize)
+			runInBackground(purger.Start)
+		}
+
+		slog.Info("API routes configured")
 
 		// Créer le serveur HTTP Gin
 		serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -91,9 +230,9 @@ puis lance le serveur HTTP.`,
 		}
 
 		go func() {
-			log.Printf("Serveur HTTP démarré sur le port %d", cfg.Server.Port)
+			slog.Info("HTTP server started", "port", cfg.Server.Port)
 			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("Erreur lors du démarrage du serveur: %v", err)
+				fatal("Failed to start HTTP server", "error", err)
 			}
 		}()
 
@@ -103,16 +242,114 @@ puis lance le serveur HTTP.`,
 
 		// Bloquer jusqu'à ce qu'un signal d'arrêt soit reçu.
 		<-quit
-		log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
+		slog.Info("Shutdown signal received, stopping server")
 
-		// Arrêt propre du serveur HTTP avec un timeout.
-		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
-		time.Sleep(5 * time.Second)
+		shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		before := pipeline.Stats()
+		complete := true // Passe à false dès qu'une étape n'a pas terminé dans le délai d'arrêt
 
-		log.Println("Serveur arrêté proprement.")
+		// 1. Plus aucune nouvelle connexion ; les redirections en cours se terminent.
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Warn("HTTP server shutdown incomplete", "error", err)
+			complete = false
+		}
+
+		// 2. Arrêt du moniteur, de l'analyse des listes de blocage et de la purge :
+		// la vérification ou le lot de purge en cours se termine, dans le délai d'arrêt.
+		cancelBackground()
+		if err := waitGroupDone(ctx, &background); err != nil {
+			slog.Warn("Shutdown timeout exceeded before background tasks stopped", "error", err)
+			complete = false
+		}
+
+		// Les alertes déjà émises par le moniteur sont envoyées avant de quitter.
+		if err := dispatcher.Stop(ctx); err != nil {
+			slog.Warn("Shutdown timeout exceeded before all notifications were sent", "error", err)
+			complete = false
+		}
+
+		// 3. Le pipeline transmet le contenu du journal, vide le channel et écrit les derniers lots.
+		if err := pipeline.Stop(ctx); err != nil {
+			slog.Warn("Shutdown timeout exceeded before all clicks were written", "timeout", shutdownTimeout.String())
+			complete = false
+		}
+
+		after := pipeline.Stats()
+		flushed := after.Recorded - before.Recorded
+		// Les lots abandonnés après leurs nouvelles tentatives sont perdus. Sans journal, les clics encore
+		// en file ou en cours d'écriture le sont aussi ; avec un journal, ils seront rejoués au prochain démarrage.
+		lost := after.Failed - before.Failed
+		if clickJournal != nil {
+			if err := clickJournal.Close(); err != nil {
+				slog.Warn("Failed to close click journal", "error", err)
+				complete = false
+			}
+			slog.Info("Clicks flushed during shutdown",
+				"recorded", flushed, "lost", lost, "journal_pending", clickJournal.Pending(), "dropped_since_start", after.Dropped)
+		} else {
+			lost += int64(after.Queued) + after.InFlight
+			slog.Info("Clicks flushed during shutdown",
+				"recorded", flushed, "lost", lost, "dropped_since_start", after.Dropped)
+		}
+
+		if !complete || lost > 0 {
+			slog.Warn("Server stopped before shutdown completed", "lost", lost, "timeout", shutdownTimeout.String())
+			return
+		}
+		slog.Info("Server stopped gracefully")
 	},
 }
 
+// buildNotifiers crée les canaux d'alerte configurés sous monitor.notifications.
+func buildNotifiers(cfg *config.Config) ([]notify.Notifier, error) {
+	settings := cfg.Monitor.Notifications
+	var notifiers []notify.Notifier
+	for _, webhook := range settings.Webhooks {
+		if webhook.URL == "" {
+			return nil, errors.New("webhook url is required")
+		}
+		notifiers = append(notifiers, notify.NewWebhookNotifier(webhook.URL, webhook.Secret, settings.WebhookRetries))
+	}
+	if settings.SMTP.Host != "" {
+		smtpNotifier, err := notify.NewSMTPNotifier(notify.SMTPOptions{
+			Host:     settings.SMTP.Host,
+			Port:     settings.SMTP.Port,
+			Username: settings.SMTP.Username,
+			Password: settings.SMTP.Password,
+			From:     settings.SMTP.From,
+			To:       settings.SMTP.To,
+		})
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, smtpNotifier)
+	}
+	return notifiers, nil
+}
+
+// waitGroupDone attend la fin des goroutines de wg, au plus jusqu'à l'annulation de ctx.
+func waitGroupDone(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fatal journalise une erreur empêchant le démarrage du serveur puis termine le processus.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func init() {
 	cmd2.RootCmd.AddCommand(RunServerCmd)
 }
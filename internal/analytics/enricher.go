@@ -0,0 +1,52 @@
+package analytics
+
+import "github.com/axellelanca/urlshortener/internal/models"
+
+// Enricher complète un clic brut avec les informations dérivées de son adresse IP (géolocalisation)
+// et de ses en-têtes (domaine référent, navigateur, système, appareil, robot).
+// L'adresse IP est traitée dès la réception du clic par ProtectEvent ; les en-têtes sont analysés
+// par les workers juste avant la persistance, avec Enrich.
+type Enricher struct {
+	geo        GeoResolver // nil si aucune base GeoIP n'est configurée : la géolocalisation est alors ignorée
+	anonymizer *Anonymizer // nil pour conserver les adresses IP telles quelles
+}
+
+// NewEnricher crée un Enricher. geo peut être nil pour désactiver la géolocalisation,
+// anonymizer peut être nil pour conserver les adresses IP complètes.
+func NewEnricher(geo GeoResolver, anonymizer *Anonymizer) *Enricher {
+	return &Enricher{geo: geo, anonymizer: anonymizer}
+}
+
+// ProtectEvent géolocalise l'adresse IP d'un événement de clic puis lui applique le mode de conservation
+// (analytics.ip_mode). Elle est appelée avant que l'événement ne soit journalisé sur disque ou mis en file :
+// l'adresse complète ne quitte pas la requête de redirection. Un événement déjà traité n'est pas modifié.
+func (e *Enricher) ProtectEvent(event *models.ClickEvent) {
+	if event.IPProtected {
+		return
+	}
+
+	if e.geo != nil && event.IP != "" {
+		if location, ok := e.geo.Lookup(event.IP); ok {
+			event.Country = location.Country
+			event.Region = location.Region
+			event.City = location.City
+		}
+	}
+
+	if e.anonymizer != nil {
+		event.IP = e.anonymizer.Anonymize(event.IP, event.Timestamp)
+	}
+	event.IPProtected = true
+}
+
+// Enrich renseigne les champs dérivés du clic à partir de Referrer et UserAgent.
+func (e *Enricher) Enrich(click *models.Click) {
+	click.ReferrerDomain = NormalizeReferrer(click.Referrer)
+	click.Referrer = TruncateReferrer(click.Referrer)
+
+	ua := ParseUserAgent(click.UserAgent)
+	click.Browser = ua.Browser
+	click.OS = ua.OS
+	click.DeviceType = ua.DeviceType
+	click.IsBot = ua.IsBot
+}
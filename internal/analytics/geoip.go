@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// UnknownLocation est le libellé utilisé lorsqu'une adresse IP n'a pas pu être localisée.
+const UnknownLocation = "Unknown"
+
+// GeoLocation est la localisation approximative d'une adresse IP.
+type GeoLocation struct {
+	Country string // Code pays ISO 3166-1 alpha-2 (ex: "FR")
+	Region  string // Nom de la première subdivision (région, état...)
+	City    string // Nom de la ville
+}
+
+// GeoResolver résout une adresse IP en localisation.
+// Il retourne false si l'adresse est invalide ou absente de la base.
+type GeoResolver interface {
+	Lookup(ip string) (GeoLocation, bool)
+}
+
+// mmdbCity décrit les champs lus dans une base au format MaxMind (GeoLite2/GeoIP2 City ou Country).
+// Les bases "Country" n'ont simplement ni subdivisions ni ville.
+type mmdbCity struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// MMDBResolver est un GeoResolver fondé sur un fichier .mmdb local.
+// Aucune requête réseau n'est effectuée : le fichier est projeté en mémoire à l'ouverture.
+type MMDBResolver struct {
+	reader *maxminddb.Reader
+}
+
+// OpenGeoIPDatabase ouvre une base de géolocalisation au format MaxMind (.mmdb).
+func OpenGeoIPDatabase(path string) (*MMDBResolver, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+	}
+	return &MMDBResolver{reader: reader}, nil
+}
+
+// Lookup localise une adresse IP. Les noms sont retournés en anglais.
+func (r *MMDBResolver) Lookup(ip string) (GeoLocation, bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return GeoLocation{}, false
+	}
+
+	var record mmdbCity
+	if err := r.reader.Lookup(parsed, &record); err != nil || record.Country.ISOCode == "" {
+		return GeoLocation{}, false
+	}
+
+	location := GeoLocation{
+		Country: record.Country.ISOCode,
+		City:    record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 {
+		location.Region = record.Subdivisions[0].Names["en"]
+	}
+	return location, true
+}
+
+// Close libère le fichier de la base.
+func (r *MMDBResolver) Close() error {
+	return r.reader.Close()
+}
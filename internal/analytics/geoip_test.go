@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// writeTestGeoIPDatabase génère une petite base .mmdb au format City
+// qui localise 81.2.69.0/24 à Paris (FR) et 2001:db8::/32 au Canada (sans région ni ville).
+func writeTestGeoIPDatabase(t *testing.T) string {
+	t.Helper()
+
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType:            "GeoIP2-City",
+		RecordSize:              24,
+		IncludeReservedNetworks: true,
+	})
+	if err != nil {
+		t.Fatalf("mmdbwriter.New: %v", err)
+	}
+
+	records := map[string]mmdbtype.Map{
+		"81.2.69.0/24": {
+			"country": mmdbtype.Map{"iso_code": mmdbtype.String("FR")},
+			"subdivisions": mmdbtype.Slice{
+				mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("Île-de-France")}},
+			},
+			"city": mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("Paris")}},
+		},
+		"2001:db8::/32": {
+			"country": mmdbtype.Map{"iso_code": mmdbtype.String("CA")},
+		},
+	}
+	for cidr, record := range records {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("ParseCIDR(%s): %v", cidr, err)
+		}
+		if err := tree.Insert(network, record); err != nil {
+			t.Fatalf("Insert(%s): %v", cidr, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "test-city.mmdb")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := tree.WriteTo(file); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	return path
+}
+
+func TestMMDBResolverLookup(t *testing.T) {
+	resolver, err := OpenGeoIPDatabase(writeTestGeoIPDatabase(t))
+	if err != nil {
+		t.Fatalf("OpenGeoIPDatabase: %v", err)
+	}
+	defer resolver.Close()
+
+	tests := []struct {
+		name   string
+		ip     string
+		want   GeoLocation
+		wantOK bool
+	}{
+		{"city record", "81.2.69.142", GeoLocation{Country: "FR", Region: "Île-de-France", City: "Paris"}, true},
+		{"country only record", "2001:db8::1", GeoLocation{Country: "CA"}, true},
+		{"address not in database", "8.8.8.8", GeoLocation{}, false},
+		{"invalid address", "not-an-ip", GeoLocation{}, false},
+		{"empty address", "", GeoLocation{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolver.Lookup(tt.ip)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Lookup(%q) = %+v, %v; want %+v, %v", tt.ip, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEnricherGeolocation(t *testing.T) {
+	resolver, err := OpenGeoIPDatabase(writeTestGeoIPDatabase(t))
+	if err != nil {
+		t.Fatalf("OpenGeoIPDatabase: %v", err)
+	}
+	defer resolver.Close()
+
+	t.Run("with database", func(t *testing.T) {
+		event := &models.ClickEvent{IP: "81.2.69.142", Timestamp: time.Now()}
+		NewEnricher(resolver, nil).ProtectEvent(event)
+		if event.Country != "FR" || event.Region != "Île-de-France" || event.City != "Paris" {
+			t.Errorf("location = %q/%q/%q; want FR/Île-de-France/Paris", event.Country, event.Region, event.City)
+		}
+	})
+
+	t.Run("without database", func(t *testing.T) {
+		event := &models.ClickEvent{IP: "81.2.69.142", Timestamp: time.Now()}
+		NewEnricher(nil, nil).ProtectEvent(event)
+		if event.Country != "" || event.Region != "" || event.City != "" {
+			t.Errorf("location = %q/%q/%q; want empty fields", event.Country, event.Region, event.City)
+		}
+		if event.IP != "81.2.69.142" {
+			t.Errorf("IP = %q; want it unchanged", event.IP)
+		}
+	})
+
+	// La localisation est résolue sur l'adresse complète, avant son empreinte.
+	t.Run("with anonymizer", func(t *testing.T) {
+		anonymizer, err := NewAnonymizer(IPModeHash, "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := &models.ClickEvent{IP: "81.2.69.142", Timestamp: time.Now()}
+		enricher := NewEnricher(resolver, anonymizer)
+		enricher.ProtectEvent(event)
+		if event.Country != "FR" || event.IP == "81.2.69.142" || !event.IPProtected {
+			t.Fatalf("event = %q/%q protected=%v; want FR, a hashed address, protected", event.Country, event.IP, event.IPProtected)
+		}
+		// Un événement relu du journal ne reçoit pas une seconde empreinte.
+		hashed := event.IP
+		enricher.ProtectEvent(event)
+		if event.IP != hashed {
+			t.Errorf("IP after second ProtectEvent = %q, want %q", event.IP, hashed)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Modes de conservation de l'adresse IP des clics (analytics.ip_mode).
+const (
+	IPModeFull     = "full"     // Adresse IP conservée telle quelle
+	IPModeTruncate = "truncate" // Dernier octet IPv4 / 80 derniers bits IPv6 mis à zéro
+	IPModeHash     = "hash"     // Empreinte HMAC avec un sel quotidien (comptage de visiteurs uniques sur une journée)
+	IPModeNone     = "none"     // Aucune adresse IP conservée
+)
+
+// hashedIPLength est la longueur (en caractères hexadécimaux) des empreintes conservées en mode hash.
+const hashedIPLength = 32
+
+// Anonymizer applique le mode de confidentialité configuré aux adresses IP avant leur persistance.
+// En mode hash, le sel est dérivé chaque jour (UTC) de la clé secrète : deux clics d'une même IP
+// le même jour ont la même empreinte, mais les empreintes ne sont pas rapprochables d'un jour à l'autre.
+type Anonymizer struct {
+	mode   string
+	secret []byte
+
+	mu       sync.Mutex
+	saltDay  string // Jour (AAAA-MM-JJ) du sel courant
+	dailyKey []byte // Sel du jour, dérivé de secret
+}
+
+// NewAnonymizer crée un Anonymizer pour le mode donné (full par défaut si vide).
+// secret n'est utilisé qu'en mode hash ; s'il est vide, une clé aléatoire est générée,
+// ce qui rend les empreintes non comparables après un redémarrage.
+func NewAnonymizer(mode, secret string) (*Anonymizer, error) {
+	if mode == "" {
+		mode = IPModeFull
+	}
+
+	switch mode {
+	case IPModeFull, IPModeTruncate, IPModeNone:
+		return &Anonymizer{mode: mode}, nil
+	case IPModeHash:
+		key := []byte(secret)
+		if len(key) == 0 {
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("failed to generate ip hash secret: %w", err)
+			}
+		}
+		return &Anonymizer{mode: mode, secret: key}, nil
+	default:
+		return nil, fmt.Errorf("unknown ip mode '%s' (expected full, truncate, hash or none)", mode)
+	}
+}
+
+// Mode retourne le mode de confidentialité appliqué.
+func (a *Anonymizer) Mode() string {
+	return a.mode
+}
+
+// Anonymize retourne la valeur à conserver pour l'adresse IP d'un clic survenu à l'instant at.
+func (a *Anonymizer) Anonymize(ip string, at time.Time) string {
+	if ip == "" {
+		return ""
+	}
+
+	switch a.mode {
+	case IPModeTruncate:
+		return TruncateIP(ip)
+	case IPModeHash:
+		mac := hmac.New(sha256.New, a.keyFor(at))
+		mac.Write([]byte(ip))
+		return hex.EncodeToString(mac.Sum(nil))[:hashedIPLength]
+	case IPModeNone:
+		return ""
+	default:
+		return ip
+	}
+}
+
+// keyFor retourne le sel du jour UTC de at, en le renouvelant au changement de jour.
+func (a *Anonymizer) keyFor(at time.Time) []byte {
+	day := at.UTC().Format("2006-01-02")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if day != a.saltDay {
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(day))
+		a.dailyKey = mac.Sum(nil)
+		a.saltDay = day
+	}
+	return a.dailyKey
+}
+
+// TruncateIP met à zéro le dernier octet d'une adresse IPv4 ou les 80 derniers bits d'une adresse IPv6.
+// Une valeur qui n'est pas une adresse IP valide est supprimée.
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// DirectReferrer est le libellé utilisé pour les clics sans référent (accès direct, e-mail, application...).
+const DirectReferrer = "(direct)"
+
+// MaxReferrerLength est la taille maximale du référent brut conservé en base.
+const MaxReferrerLength = 512
+
+// NormalizeReferrer réduit un en-tête Referer au domaine référent :
+// schéma, chemin, port et préfixe "www." sont retirés, et le domaine est mis en minuscules.
+// Elle retourne une chaîne vide si le référent est absent ou inexploitable.
+func NormalizeReferrer(referrer string) string {
+	referrer = strings.TrimSpace(referrer)
+	if referrer == "" {
+		return ""
+	}
+
+	u, err := url.Parse(referrer)
+	if err != nil || u.Host == "" {
+		// Certains clients envoient un référent sans schéma (ex: "example.com/page").
+		u, err = url.Parse("http://" + referrer)
+		if err != nil || u.Host == "" {
+			return ""
+		}
+	}
+
+	host := u.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	host = strings.TrimPrefix(host, "www.")
+	return host
+}
+
+// TruncateReferrer limite la longueur du référent brut à MaxReferrerLength octets.
+func TruncateReferrer(referrer string) string {
+	if len(referrer) <= MaxReferrerLength {
+		return referrer
+	}
+	return referrer[:MaxReferrerLength]
+}
@@ -0,0 +1,124 @@
+package analytics
+
+import "strings"
+
+// Types d'appareils reconnus par ParseUserAgent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceUnknown = "unknown"
+)
+
+// Unknown est la valeur utilisée lorsqu'un navigateur ou un système n'est pas reconnu.
+const Unknown = "Other"
+
+// UserAgentInfo est le résultat de l'analyse d'un en-tête User-Agent.
+type UserAgentInfo struct {
+	Browser    string // Famille de navigateur (Chrome, Firefox, Safari...)
+	OS         string // Famille de système d'exploitation (Windows, iOS, Android...)
+	DeviceType string // desktop, mobile, tablet ou unknown
+	IsBot      bool   // true pour les robots d'indexation, aperçus de liens et clients HTTP scriptés
+}
+
+// uaRule associe des fragments (en minuscules) recherchés dans le User-Agent à une famille.
+// Les règles sont évaluées dans l'ordre : la première dont un fragment est présent l'emporte.
+type uaRule struct {
+	tokens []string
+	name   string
+}
+
+// botTokens identifie les robots, les générateurs d'aperçus des messageries et les clients scriptés.
+// Ce sont eux qui gonflent les compteurs dès qu'un lien est partagé dans une conversation.
+var botTokens = []string{
+	"bot", "crawler", "spider", "slurp", "crawl", "preview", "headless", "lighthouse",
+	"facebookexternalhit", "facebookcatalog", "whatsapp", "skypeuripreview", "viber",
+	"vkshare", "embedly", "iframely", "pinterest",
+	"bitlybot", "outbrain", "google-inspectiontool", "mediapartners-google", "adsbot",
+	"curl/", "wget/", "python-requests", "python-urllib", "aiohttp", "go-http-client",
+	"java/", "okhttp", "apache-httpclient", "axios/", "node-fetch", "undici", "libwww-perl",
+	"scrapy", "phantomjs", "httpclient", "postmanruntime", "insomnia",
+}
+
+// browserRules est ordonnée des navigateurs les plus spécifiques aux plus génériques :
+// Edge, Opera ou Samsung Internet contiennent aussi "Chrome/" et "Safari/" dans leur User-Agent.
+var browserRules = []uaRule{
+	{[]string{"fban", "fbav", "fb_iab"}, "Facebook App"},
+	{[]string{"instagram"}, "Instagram App"},
+	{[]string{"edg/", "edge/", "edga/", "edgios/"}, "Edge"},
+	{[]string{"opr/", "opera", "opios/"}, "Opera"},
+	{[]string{"samsungbrowser"}, "Samsung Internet"},
+	{[]string{"yabrowser"}, "Yandex Browser"},
+	{[]string{"vivaldi"}, "Vivaldi"},
+	{[]string{"ucbrowser"}, "UC Browser"},
+	{[]string{"firefox/", "fxios/"}, "Firefox"},
+	{[]string{"chrome/", "crios/", "chromium/"}, "Chrome"},
+	{[]string{"msie ", "trident/"}, "Internet Explorer"},
+	{[]string{"safari/"}, "Safari"},
+}
+
+// osRules est ordonnée pour que les systèmes dérivés soient reconnus avant leur parent :
+// Android contient "Linux", et iOS contient "like Mac OS X".
+var osRules = []uaRule{
+	{[]string{"windows phone"}, "Windows Phone"},
+	{[]string{"windows"}, "Windows"},
+	{[]string{"android"}, "Android"},
+	{[]string{"iphone", "ipad", "ipod"}, "iOS"},
+	{[]string{"mac os x", "macintosh"}, "macOS"},
+	{[]string{"cros "}, "Chrome OS"},
+	{[]string{"linux", "x11"}, "Linux"},
+}
+
+// tabletTokens et mobileTokens déterminent le type d'appareil ; les tablettes sont testées en premier.
+var (
+	tabletTokens = []string{"ipad", "tablet", "kindle", "silk/", "playbook"}
+	mobileTokens = []string{"mobi", "iphone", "ipod", "windows phone", "blackberry", "opera mini", "android"}
+)
+
+// ParseUserAgent analyse un en-tête User-Agent à l'aide des règles embarquées, sans aucun appel réseau.
+// Un User-Agent vide donne des familles inconnues sans être considéré comme un robot.
+func ParseUserAgent(userAgent string) UserAgentInfo {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return UserAgentInfo{Browser: Unknown, OS: Unknown, DeviceType: DeviceUnknown}
+	}
+
+	info := UserAgentInfo{
+		Browser: matchRule(ua, browserRules),
+		OS:      matchRule(ua, osRules),
+		IsBot:   containsAny(ua, botTokens),
+	}
+
+	switch {
+	case info.IsBot:
+		info.DeviceType = DeviceUnknown
+	case containsAny(ua, tabletTokens), strings.Contains(ua, "android") && !strings.Contains(ua, "mobi"):
+		// Les tablettes Android n'annoncent pas "Mobile" dans leur User-Agent.
+		info.DeviceType = DeviceTablet
+	case containsAny(ua, mobileTokens):
+		info.DeviceType = DeviceMobile
+	default:
+		info.DeviceType = DeviceDesktop
+	}
+	return info
+}
+
+// matchRule retourne le nom de la première règle dont un fragment apparaît dans ua.
+func matchRule(ua string, rules []uaRule) string {
+	for _, rule := range rules {
+		if containsAny(ua, rule.tokens) {
+			return rule.name
+		}
+	}
+	return Unknown
+}
+
+// containsAny indique si s contient au moins un des fragments.
+func containsAny(s string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.Contains(s, token) {
+			return true
+		}
+	}
+	return false
+}
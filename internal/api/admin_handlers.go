@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListQuarantinedLinksHandler retourne les liens en quarantaine, à examiner (GET /api/v1/admin/quarantine).
+func ListQuarantinedLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		links, err := linkService.ListQuarantinedLinks()
+		if err != nil {
+			requestLogger(c).Error("Error listing quarantined links", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(links))
+		for i := range links {
+			items = append(items, linkResponse(&links[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{"links": items})
+	}
+}
+
+// ReleaseQuarantineHandler sort un lien de quarantaine après examen (POST /api/v1/admin/quarantine/:shortCode/release).
+func ReleaseQuarantineHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.ReleaseQuarantine(principalFrom(c), shortCode)
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "link not found"})
+			case errors.Is(err, services.ErrAdminRequired):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrLinkNotQuarantined):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				requestLogger(c).Error("Error releasing link from quarantine", "short_code", shortCode, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			}
+			return
+		}
+
+		requestLogger(c).Info("Link released from quarantine", "short_code", link.Shortcode, "long_url", link.LongURL)
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
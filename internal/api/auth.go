@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader est l'en-tête alternatif à "Authorization: Bearer" pour transmettre une clé d'API.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyContextKey est la clé du contexte Gin sous laquelle la clé d'API authentifiée est stockée.
+const apiKeyContextKey = "api_key"
+
+// Authenticate exige une clé d'API valide, transmise dans "Authorization: Bearer <clé>" ou dans X-API-Key.
+// Une clé absente, inconnue, révoquée ou expirée est refusée avec un 401.
+func Authenticate(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := apiKeyFromRequest(c)
+		if token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing api key"})
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(token)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				requestLogger(c).Debug("Rejected API key", "error", err)
+				c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				return
+			}
+			requestLogger(c).Error("Error authenticating API key", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// RequireScope refuse avec un 403 les requêtes dont la clé d'API n'accorde pas le scope donné.
+// Il doit être placé après Authenticate.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := apiKeyFrom(c)
+		if key == nil || !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key lacks required scope", "required_scope": scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiKeyFromRequest extrait la clé d'API des en-têtes de la requête (vide si absente).
+func apiKeyFromRequest(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		scheme, token, ok := strings.Cut(auth, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return strings.TrimSpace(c.GetHeader(APIKeyHeader))
+}
+
+// principalFrom retourne le principal de la requête en cours, déduit de sa clé d'API.
+// Hors middleware Authenticate, il ne donne accès à aucun lien.
+func principalFrom(c *gin.Context) services.Principal {
+	if key := apiKeyFrom(c); key != nil {
+		return services.PrincipalForKey(key)
+	}
+	return services.Principal{}
+}
+
+// apiKeyFrom retourne la clé d'API authentifiée de la requête en cours (nil hors middleware Authenticate).
+func apiKeyFrom(c *gin.Context) *models.APIKey {
+	key, _ := c.Get(apiKeyContextKey)
+	apiKey, _ := key.(*models.APIKey)
+	return apiKey
+}
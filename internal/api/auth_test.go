@@ -0,0 +1,214 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// testServer regroupe un routeur configuré comme celui du serveur et les services sur lesquels il s'appuie.
+type testServer struct {
+	router  *gin.Engine
+	links   repository.LinkRepository
+	users   *services.UserService
+	apiKeys *services.APIKeyService
+}
+
+// newTestServer construit les routes de l'API sur une base temporaire.
+// Le pipeline de clics n'est pas démarré : les clics des redirections restent dans son channel.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	db := repositorytest.OpenDatabase(t)
+	s := &testServer{
+		router:  gin.New(),
+		links:   repository.NewLinkRepository(db),
+		users:   services.NewUserService(repository.NewUserRepository(db)),
+		apiKeys: services.NewAPIKeyService(repository.NewAPIKeyRepository(db)),
+	}
+	clickRepo := repository.NewClickRepository(db)
+	pipeline := workers.NewClickPipeline(clickRepo, analytics.NewEnricher(nil, nil), workers.PipelineOptions{})
+	policy, err := urlpolicy.New(urlpolicy.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.router.Use(RequestID())
+	SetupRoutes(s.router,
+		services.NewLinkService(s.links, policy, nil),
+		services.NewClickService(clickRepo, 0),
+		services.NewHealthService(repository.NewLinkCheckRepository(db)),
+		s.apiKeys,
+		pipeline,
+		metrics.New(prometheus.NewRegistry()),
+	)
+	return s
+}
+
+// createUser crée un utilisateur et retourne son identifiant.
+func (s *testServer) createUser(t *testing.T, name string) *uint {
+	t.Helper()
+	user, err := s.users.CreateUser(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &user.ID
+}
+
+// createKey crée une clé d'API et retourne la clé en clair.
+func (s *testServer) createKey(t *testing.T, userID *uint, scopes ...string) string {
+	t.Helper()
+	_, token, err := s.apiKeys.CreateKey("test", userID, scopes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// createLink enregistre directement un lien en base.
+func (s *testServer) createLink(t *testing.T, shortCode string, ownerID *uint) {
+	t.Helper()
+	if err := s.links.CreateLink(&models.Link{Shortcode: shortCode, LongURL: "https://example.com/" + shortCode, OwnerID: ownerID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// do exécute une requête sur le routeur, authentifiée par "Authorization: Bearer" si token n'est pas vide.
+func (s *testServer) do(method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthenticateRejectsMissingOrInvalidKeys(t *testing.T) {
+	s := newTestServer(t)
+	userID := s.createUser(t, "alice")
+	token := s.createKey(t, userID, models.ScopeLinksRead)
+
+	revoked, revokedToken, err := s.apiKeys.CreateKey("revoked", userID, []string{models.ScopeLinksRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.apiKeys.RevokeKey(revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"missing key", "", "", http.StatusUnauthorized},
+		{"unknown key", "Authorization", "Bearer usk_unknown", http.StatusUnauthorized},
+		{"malformed key", "X-API-Key", "not-a-key", http.StatusUnauthorized},
+		{"wrong scheme", "Authorization", "Basic " + token, http.StatusUnauthorized},
+		{"revoked key", "Authorization", "Bearer " + revokedToken, http.StatusUnauthorized},
+		{"bearer key", "Authorization", "Bearer " + token, http.StatusOK},
+		{"header key", "X-API-Key", token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: missing WWW-Authenticate header", tt.name)
+		}
+	}
+}
+
+func TestRequireScopeRejectsInsufficientScopes(t *testing.T) {
+	s := newTestServer(t)
+	userID := s.createUser(t, "alice")
+	s.createLink(t, "alice1", userID)
+	readOnly := s.createKey(t, userID, models.ScopeLinksRead)
+	stats := s.createKey(t, userID, models.ScopeStatsRead)
+	admin := s.createKey(t, nil, models.ScopeAdmin)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   string
+		want   int
+	}{
+		{"create without links:write", http.MethodPost, "/api/v1/links", readOnly, `{"long_url":"https://example.com/new"}`, http.StatusForbidden},
+		{"delete without links:write", http.MethodDelete, "/api/v1/links/alice1", stats, "", http.StatusForbidden},
+		{"list without links:read", http.MethodGet, "/api/v1/links", stats, "", http.StatusForbidden},
+		{"stats without stats:read", http.MethodGet, "/api/v1/links/alice1/stats", readOnly, "", http.StatusForbidden},
+		{"quarantine without admin", http.MethodGet, "/api/v1/admin/quarantine", stats, "", http.StatusForbidden},
+		{"stats with stats:read", http.MethodGet, "/api/v1/links/alice1/stats", stats, "", http.StatusOK},
+		// Le scope admin implique tous les autres.
+		{"create with admin", http.MethodPost, "/api/v1/links", admin, `{"long_url":"https://example.com/new"}`, http.StatusCreated},
+		{"quarantine with admin", http.MethodGet, "/api/v1/admin/quarantine", admin, "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := s.do(tt.method, tt.path, tt.token, tt.body); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestMetricsRequiresAdminScope(t *testing.T) {
+	s := newTestServer(t)
+	userID := s.createUser(t, "alice")
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"no key", "", http.StatusUnauthorized},
+		{"all scopes but admin", s.createKey(t, userID, models.ScopeLinksWrite, models.ScopeLinksRead, models.ScopeStatsRead), http.StatusForbidden},
+		{"admin", s.createKey(t, nil, models.ScopeAdmin), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := s.do(http.MethodGet, "/metrics", tt.token, ""); w.Code != tt.want {
+			t.Errorf("%s: GET /metrics status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestRedirectsAndHealthArePublic(t *testing.T) {
+	s := newTestServer(t)
+	s.createLink(t, "public", s.createUser(t, "alice"))
+
+	w := s.do(http.MethodGet, "/public", "", "")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/public" {
+		t.Errorf("GET /public status = %d, location = %q; want a redirect to the long url", w.Code, w.Header().Get("Location"))
+	}
+	if w := s.do(http.MethodGet, "/health", "", ""); w.Code != http.StatusOK {
+		t.Errorf("GET /health status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
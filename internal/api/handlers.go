@@ -2,51 +2,58 @@ package api
 
 import (
 	"errors"
-	"log"
 	"net/http"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
 )
 
-// Événement de clic envoyé au worker asynchrone.
-type ClickEvent struct {
-	ShortCode string
-	LongURL   string
-	Timestamp time.Time
-	IP        string
-	UserAgent string
-	Referrer  string
-}
-
-// Channel global bufferisé utilisé par les workers pour consommer les clics.
-var ClickEventsChannel chan ClickEvent
-
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires.
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService, bufferSize int) {
-	// Initialisation du channel des événements de clics.
-	if ClickEventsChannel == nil {
-		if bufferSize <= 0 {
-			bufferSize = 100
-		}
-		ClickEventsChannel = make(chan ClickEvent, bufferSize)
-	}
-
+// Les clics des redirections sont transmis au pipeline, qui doit être démarré par l'appelant.
+// Les routes /api/v1 exigent une clé d'API portant le scope adapté ; les redirections restent publiques.
+// Les métriques sont alimentées par les handlers et exposées sur /metrics, réservée au scope admin
+// parce qu'elle liste les short codes de tous les liens surveillés.
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickService *services.ClickService, healthService *services.HealthService, apiKeyService *services.APIKeyService, pipeline *workers.ClickPipeline, m *metrics.Metrics) {
 	// Route de Health Check.
 	router.GET("/health", HealthCheckHandler)
 
+	// Métriques Prometheus.
+	router.GET("/metrics", Authenticate(apiKeyService), RequireScope(models.ScopeAdmin), m.Handler())
+
 	// Routes API versionnées.
-	api := router.Group("/api/v1")
+	api := router.Group("/api/v1", Authenticate(apiKeyService))
 	{
-		api.POST("/links", CreateShortLinkHandler(linkService))
-		api.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+		write := RequireScope(models.ScopeLinksWrite)
+		api.POST("/links", write, CreateShortLinkHandler(linkService, m))
+		api.PATCH("/links/:shortCode", write, UpdateLinkHandler(linkService))
+		api.DELETE("/links/:shortCode", write, DeleteLinkHandler(linkService))
+		api.POST("/links/:shortCode/restore", write, RestoreLinkHandler(linkService))
+
+		api.GET("/links", RequireScope(models.ScopeLinksRead), ListLinksHandler(linkService))
+
+		stats := RequireScope(models.ScopeStatsRead)
+		api.GET("/links/:shortCode/stats", stats, GetLinkStatsHandler(linkService))
+		api.GET("/links/:shortCode/stats/timeseries", stats, GetLinkTimeSeriesHandler(linkService, clickService))
+		api.GET("/links/:shortCode/stats/devices", stats, GetLinkDevicesHandler(linkService, clickService))
+		api.GET("/links/:shortCode/stats/geo", stats, GetLinkGeoHandler(linkService, clickService))
+		api.GET("/links/:shortCode/referrers", stats, GetLinkReferrersHandler(linkService, clickService))
+		api.GET("/links/:shortCode/health", stats, GetLinkHealthHandler(linkService, healthService))
+
+		// Examen des liens mis en quarantaine par l'analyse des listes de blocage.
+		admin := RequireScope(models.ScopeAdmin)
+		api.GET("/admin/quarantine", admin, ListQuarantinedLinksHandler(linkService))
+		api.POST("/admin/quarantine/:shortCode/release", admin, ReleaseQuarantineHandler(linkService))
 	}
 
 	// Route de redirection pour les short codes.
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	router.GET("/:shortCode", m.ObserveRedirect(), RedirectHandler(linkService, pipeline))
 }
 
 // HealthCheckHandler gère la route /health pour vérifier l'état du service.
@@ -56,11 +63,15 @@ func HealthCheckHandler(c *gin.Context) {
 
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
-	LongURL string `json:"long_url" binding:"required,url"`
+	LongURL     string     `json:"long_url" binding:"required,url"`
+	Alias       string     `json:"alias"`                                // Alias personnalisé optionnel (ex: "spring-sale")
+	ExpiresAt   *time.Time `json:"expires_at"`                           // Date d'expiration optionnelle (RFC 3339)
+	MaxClicks   int        `json:"max_clicks" binding:"omitempty,min=1"` // Nombre maximal de redirections optionnel
+	FallbackURL string     `json:"fallback_url" binding:"omitempty,url"` // URL de repli optionnelle, servie si long_url est en panne
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
-func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+func CreateShortLinkHandler(linkService *services.LinkService, m *metrics.Metrics) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req CreateLinkRequest
 
@@ -69,83 +80,234 @@ func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
 			return
 		}
 
-		link, err := linkService.CreateLink(req.LongURL)
+		link, err := linkService.CreateLink(principalFrom(c), req.LongURL, services.CreateLinkOptions{
+			Alias:       req.Alias,
+			ExpiresAt:   req.ExpiresAt,
+			MaxClicks:   req.MaxClicks,
+			FallbackURL: req.FallbackURL,
+		})
 		if err != nil {
-			// Si ton service a des erreurs métiers (URL invalide, etc.), tu peux les tester ici
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create link"})
+			switch {
+			case errors.Is(err, services.ErrInvalidAlias), errors.Is(err, services.ErrInvalidLinkOptions),
+				errors.Is(err, services.ErrURLRejected), errors.Is(err, services.ErrURLBlocked):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrAliasTaken):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				requestLogger(c).Error("Error creating link", "long_url", req.LongURL, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create link"})
+			}
 			return
 		}
 
-		baseURL := viper.GetString("server.base_url")
-		if baseURL == "" {
-			baseURL = "http://localhost:8080"
-		}
+		m.LinkCreated()
+		c.JSON(http.StatusCreated, linkResponse(link))
+	}
+}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.Shortcode,
-			"long_url":       link.LongURL,
-			"full_short_url": baseURL + "/" + link.Shortcode,
-		})
+// linkResponse construit la représentation JSON d'un lien renvoyée par l'API.
+// Les champs optionnels (expiration, budget, état) ne sont présents que lorsqu'ils sont pertinents.
+func linkResponse(link *models.Link) gin.H {
+	baseURL := viper.GetString("server.base_url")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
 	}
+
+	response := gin.H{
+		"short_code":     link.Shortcode,
+		"long_url":       link.LongURL,
+		"full_short_url": baseURL + "/" + link.Shortcode,
+		"created_at":     link.CreatedAt,
+	}
+	if link.ExpiresAt != nil {
+		response["expires_at"] = link.ExpiresAt
+	}
+	if link.MaxClicks > 0 {
+		response["max_clicks"] = link.MaxClicks
+	}
+	if link.Disabled {
+		response["disabled"] = true
+	}
+	if link.DeletedAt.Valid {
+		response["deleted_at"] = link.DeletedAt.Time
+	}
+	if link.OwnerID != nil {
+		response["owner_id"] = *link.OwnerID
+	}
+	if link.FallbackURL != "" {
+		response["fallback_url"] = link.FallbackURL
+		response["routing"] = link.Routing()
+	}
+	if link.Quarantined {
+		response["quarantined"] = true
+		response["quarantined_at"] = link.QuarantinedAt
+		response["quarantine_reason"] = link.QuarantineReason
+	}
+	return response
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue
-// et l'enregistrement asynchrone des clics.
-func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
+// (ou vers son URL de repli tant que le moniteur la signale en panne) et l'enregistrement asynchrone des clics.
+func RedirectHandler(linkService *services.LinkService, pipeline *workers.ClickPipeline) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		// Les aperçus de liens des messageries ne doivent pas épuiser le budget de clics.
+		isBot := analytics.ParseUserAgent(c.Request.UserAgent()).IsBot
+		link, err := linkService.ResolveLink(shortCode, isBot)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "link not found"})
 				return
 			}
-			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			if errors.Is(err, services.ErrLinkDisabled) {
+				c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrLinkQuarantined) {
+				respondQuarantined(c, link)
+				return
+			}
+			if errors.Is(err, services.ErrLinkExpired) || errors.Is(err, services.ErrClickLimitReached) {
+				respondLinkGone(c, err)
+				return
+			}
+			requestLogger(c).Error("Error retrieving link", "short_code", shortCode, "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 			return
 		}
 
-		clickEvent := ClickEvent{
+		destination := link.Destination()
+		clickEvent := models.ClickEvent{
+			LinkID:    link.ID,
 			ShortCode: shortCode,
-			LongURL:   link.LongURL,
+			LongURL:   destination,
 			Timestamp: time.Now(),
-			IP:        c.ClientIP(),
-			UserAgent: c.Request.UserAgent(),
-			Referrer:  c.Request.Referer(),
+			RequestID: requestIDFrom(c),
+			IsBot:     isBot,
 		}
-
-		// Envoi non bloquant dans le channel bufferisé.
-		select {
-		case ClickEventsChannel <- clickEvent:
-		default:
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		// Les visiteurs ayant activé Do Not Track ou Global Privacy Control ne sont comptés
+		// qu'anonymement : aucune donnée personnelle (IP, User-Agent, référent) n'est transmise.
+		if !optedOutOfTracking(c.Request) {
+			clickEvent.IP = c.ClientIP()
+			clickEvent.UserAgent = c.Request.UserAgent()
+			clickEvent.Referrer = c.Request.Referer()
 		}
 
-		c.Redirect(http.StatusFound, link.LongURL)
+		// Envoi non bloquant : la redirection n'attend jamais l'enregistrement du clic.
+		pipeline.Enqueue(clickEvent)
+
+		c.Redirect(http.StatusFound, destination)
 	}
 }
 
+// optedOutOfTracking indique si la requête porte un signal de refus du suivi (DNT: 1 ou Sec-GPC: 1).
+func optedOutOfTracking(r *http.Request) bool {
+	return r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1"
+}
+
+// respondLinkGone répond à la visite d'un lien qui n'est plus utilisable (expiré ou budget de clics épuisé).
+// Si une URL de repli est configurée (server.expired_fallback_url), le visiteur y est redirigé,
+// sinon le serveur répond 410 Gone.
+func respondLinkGone(c *gin.Context, reason error) {
+	if fallbackURL := viper.GetString("server.expired_fallback_url"); fallbackURL != "" {
+		c.Redirect(http.StatusFound, fallbackURL)
+		return
+	}
+	c.JSON(http.StatusGone, gin.H{"error": reason.Error()})
+}
+
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
+// Les clics de robots sont exclus du total, sauf avec le paramètre include_bots=true.
+// Le routage en vigueur et les dernières bascules sont inclus lorsque le lien a une URL de repli ou en a eu une.
 func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		includeBots, ok := parseIncludeBots(c)
+		if !ok {
+			return
+		}
+
+		link, totalClicks, err := linkService.GetLinkStats(principalFrom(c), shortCode, includeBots)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "link not found"})
 				return
 			}
-			log.Printf("Error retrieving stats for %s: %v", shortCode, err)
+			requestLogger(c).Error("Error retrieving stats", "short_code", shortCode, "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
+		response := gin.H{
 			"short_code":   link.Shortcode,
 			"long_url":     link.LongURL,
 			"total_clicks": totalClicks,
-		})
+		}
+		if link.ExpiresAt != nil {
+			response["expires_at"] = link.ExpiresAt
+			response["expired"] = link.IsExpired(time.Now())
+		}
+		if link.MaxClicks > 0 {
+			response["max_clicks"] = link.MaxClicks
+			response["remaining_clicks"] = max(link.MaxClicks-link.UsedClicks, 0)
+		}
+		if link.Disabled {
+			response["disabled"] = true
+		}
+		if link.DeletedAt.Valid {
+			response["deleted_at"] = link.DeletedAt.Time
+		}
+		if link.Quarantined {
+			response["quarantined"] = true
+			response["quarantine_reason"] = link.QuarantineReason
+		}
+
+		events, err := linkService.GetRoutingEvents(principalFrom(c), shortCode, 10)
+		if err != nil {
+			requestLogger(c).Error("Error retrieving routing events", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		if link.FallbackURL != "" || len(events) > 0 {
+			response["routing"] = routingResponse(link, events)
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// routingResponse construit la représentation JSON du routage d'un lien : routage en vigueur,
+// URL de repli et journal des dernières bascules (de la plus récente à la plus ancienne).
+func routingResponse(link *models.Link, events []models.LinkRoutingEvent) gin.H {
+	items := make([]gin.H, 0, len(events))
+	for _, event := range events {
+		item := gin.H{
+			"occurred_at": event.OccurredAt,
+			"routing":     event.Routing,
+		}
+		if event.ConsecutiveFailures > 0 {
+			item["consecutive_failures"] = event.ConsecutiveFailures
+		}
+		if event.StatusCode != 0 {
+			item["status_code"] = event.StatusCode
+		}
+		if event.ErrorClass != "" {
+			item["error"] = event.ErrorClass
+		}
+		items = append(items, item)
+	}
+
+	routing := gin.H{
+		"state":  link.Routing(),
+		"events": items,
+	}
+	if link.FallbackURL != "" {
+		routing["fallback_url"] = link.FallbackURL
+	}
+	if link.Routing() == models.RoutingFallback && link.FailedOverAt != nil {
+		routing["since"] = link.FailedOverAt
 	}
+	return routing
 }
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetLinkHealthHandler retourne l'état de santé de l'URL longue d'un lien d'après l'historique du moniteur :
+// état actuel, disponibilité sur la période et dernières vérifications
+// (GET /api/v1/links/:shortCode/health?from=&to=&limit=).
+func GetLinkHealthHandler(linkService *services.LinkService, healthService *services.HealthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, ok := lookupStatsLink(c, linkService)
+		if !ok {
+			return
+		}
+
+		from, to, ok := parsePeriod(c, time.UTC)
+		if !ok {
+			return
+		}
+
+		limit := 20
+		if raw := c.Query("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+				return
+			}
+			limit = n
+		}
+
+		health, err := healthService.GetLinkHealth(link.ID, from, to, limit)
+		if err != nil {
+			requestLogger(c).Error("Error retrieving link health", "short_code", link.Shortcode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		checks := make([]gin.H, 0, len(health.Recent))
+		for _, check := range health.Recent {
+			checks = append(checks, checkResponse(check))
+		}
+
+		response := gin.H{
+			"short_code": link.Shortcode,
+			"long_url":   link.LongURL,
+			"state":      health.State,
+			"from":       from,
+			"to":         to,
+			"checks":     health.Checks,
+			"recent":     checks,
+		}
+		if health.LastCheck != nil {
+			response["last_checked_at"] = health.LastCheck.CheckedAt
+		}
+		if health.UptimePercent != nil {
+			response["uptime_percent"] = *health.UptimePercent
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// checkResponse construit la représentation JSON d'une vérification.
+// La chaîne de redirections et l'URL finale ne sont présentes que si l'URL a redirigé.
+func checkResponse(check models.LinkCheck) gin.H {
+	item := gin.H{
+		"checked_at": check.CheckedAt,
+		"up":         check.Up,
+		"latency_ms": check.LatencyMs,
+	}
+	if check.StatusCode != 0 {
+		item["status_code"] = check.StatusCode
+	}
+	if check.ErrorClass != "" {
+		item["error"] = check.ErrorClass
+	}
+	if len(check.RedirectChain) > 0 {
+		item["redirects"] = check.RedirectChain
+	}
+	if check.FinalURL != "" {
+		item["final_url"] = check.FinalURL
+		item["host_changed"] = check.HostChanged
+	}
+	return item
+}
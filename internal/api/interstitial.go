@@ -0,0 +1,50 @@
+package api
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// quarantinePage est la page d'avertissement affichée à la place de la redirection d'un lien en quarantaine.
+// La destination est affichée en texte brut, sans lien cliquable.
+var quarantinePage = template.Must(template.New("quarantine").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Lien bloqué</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #fdf3f2; color: #3b0d0c; margin: 0; }
+main { max-width: 40rem; margin: 10vh auto; padding: 2rem; background: #fff; border-left: 6px solid #c0392b; }
+code { word-break: break-all; background: #f6f6f6; padding: 0 .25rem; }
+</style>
+</head>
+<body>
+<main>
+<h1>Ce lien a été bloqué</h1>
+<p>La destination du lien <code>{{.Shortcode}}</code> figure dans une liste de sites malveillants
+(hameçonnage, logiciels malveillants). Par précaution, vous n'y avez pas été redirigé.</p>
+<p>Destination : <code>{{.LongURL}}</code></p>
+<p>Si vous pensez qu'il s'agit d'une erreur, contactez l'auteur du lien.</p>
+</main>
+</body>
+</html>
+`))
+
+// respondQuarantined affiche la page d'avertissement d'un lien en quarantaine (403 Forbidden),
+// pour que ni le visiteur ni les aperçus de liens ne suivent la destination.
+func respondQuarantined(c *gin.Context, link *models.Link) {
+	var page bytes.Buffer
+	if err := quarantinePage.Execute(&page, link); err != nil {
+		requestLogger(c).Error("Error rendering quarantine page", "short_code", link.Shortcode, "error", err)
+		c.JSON(http.StatusForbidden, gin.H{"error": "link is quarantined"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusForbidden, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdateLinkRequest représente le corps de la requête JSON pour la modification d'un lien.
+// Les champs absents ne sont pas modifiés.
+type UpdateLinkRequest struct {
+	LongURL     *string `json:"long_url" binding:"omitempty,url"`     // Nouvelle URL de destination
+	Disabled    *bool   `json:"disabled"`                             // Désactive (true) ou réactive (false) le lien
+	FallbackURL *string `json:"fallback_url" binding:"omitempty,url"` // Nouvelle URL de repli ("" pour la retirer)
+}
+
+// UpdateLinkHandler gère la modification de la destination ou de l'état d'un lien (PATCH).
+func UpdateLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.LongURL == nil && req.Disabled == nil && req.FallbackURL == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update: provide long_url, disabled and/or fallback_url"})
+			return
+		}
+
+		link, err := linkService.UpdateLink(principalFrom(c), shortCode, services.UpdateLinkOptions{
+			LongURL:     req.LongURL,
+			Disabled:    req.Disabled,
+			FallbackURL: req.FallbackURL,
+		})
+		if err != nil {
+			respondLifecycleError(c, shortCode, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// DeleteLinkHandler gère la suppression logique d'un lien (DELETE).
+// L'historique des clics est conservé et le lien peut être restauré.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		if err := linkService.DeleteLink(principalFrom(c), shortCode); err != nil {
+			respondLifecycleError(c, shortCode, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RestoreLinkHandler gère la restauration d'un lien supprimé logiquement.
+func RestoreLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.RestoreLink(principalFrom(c), shortCode)
+		if err != nil {
+			respondLifecycleError(c, shortCode, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// respondLifecycleError traduit les erreurs des opérations de cycle de vie en réponses HTTP.
+func respondLifecycleError(c *gin.Context, shortCode string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "link not found"})
+	case errors.Is(err, services.ErrURLRejected), errors.Is(err, services.ErrURLBlocked):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLinkNotDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		requestLogger(c).Error("Error updating lifecycle of link", "short_code", shortCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
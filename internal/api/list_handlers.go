@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListLinksHandler gère le listing paginé des liens (GET /api/v1/links).
+// Paramètres de requête acceptés : limit, cursor, sort (created_at|clicks), order (asc|desc),
+// domain (sous-chaîne du domaine de destination) et created_after (RFC 3339 ou AAAA-MM-JJ).
+func ListLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params := services.ListLinksParams{
+			Cursor: c.Query("cursor"),
+			SortBy: c.Query("sort"),
+			Order:  c.Query("order"),
+			Domain: c.Query("domain"),
+		}
+
+		if limit := c.Query("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
+				return
+			}
+			params.Limit = n
+		}
+
+		if createdAfter := c.Query("created_after"); createdAfter != "" {
+			t, err := parseTimeParam(createdAfter, time.UTC)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "created_after: " + err.Error()})
+				return
+			}
+			params.CreatedAfter = &t
+		}
+
+		page, err := linkService.ListLinks(principalFrom(c), params)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidListParams) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			requestLogger(c).Error("Error listing links", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		links := make([]gin.H, 0, len(page.Links))
+		for i := range page.Links {
+			item := linkResponse(&page.Links[i])
+			item["total_clicks"] = page.Links[i].ClickCount
+			links = append(links, item)
+		}
+
+		response := gin.H{"links": links}
+		if page.NextCursor != "" {
+			response["next_cursor"] = page.NextCursor
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// parseTimeParam interprète un paramètre de date au format RFC 3339 ou AAAA-MM-JJ.
+// Une date sans heure est interprétée à minuit dans le fuseau loc.
+func parseTimeParam(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s', expected RFC 3339 or YYYY-MM-DD", value)
+}
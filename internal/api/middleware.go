@@ -0,0 +1,107 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader est l'en-tête portant l'identifiant de requête, accepté en entrée et renvoyé en réponse.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey est la clé du contexte Gin sous laquelle l'identifiant de requête est stocké.
+const requestIDKey = "request_id"
+
+// maxRequestIDLength borne la taille d'un identifiant fourni par le client.
+const maxRequestIDLength = 128
+
+// RequestID attribue un identifiant à chaque requête, ou reprend celui fourni dans X-Request-ID
+// s'il est valide. L'identifiant est renvoyé dans la réponse et ajouté aux logs de la requête.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID accepte les identifiants courts composés de caractères ASCII imprimables sans espace.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID génère un identifiant aléatoire de 16 octets en hexadécimal.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDFrom retourne l'identifiant de la requête en cours (vide hors middleware RequestID).
+func requestIDFrom(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestLogger retourne le logger par défaut enrichi de l'identifiant de la requête.
+func requestLogger(c *gin.Context) *slog.Logger {
+	return slog.Default().With("request_id", requestIDFrom(c))
+}
+
+// AccessLog remplace le logger d'accès de gin.Default() : une ligne structurée par requête,
+// au format du logger par défaut (texte ou JSON).
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []any{
+			"request_id", requestIDFrom(c),
+			"method", c.Request.Method,
+			"path", path,
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		slog.Log(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// Recovery remplace le middleware de récupération de gin.Default() : une panique dans un handler
+// est journalisée avec sa pile d'appels au format structuré et la requête reçoit une erreur 500.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		requestLogger(c).Error("panic while handling request",
+			"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	})
+}
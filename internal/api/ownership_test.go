@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// listedShortCodes retourne les codes courts d'une réponse de GET /api/v1/links.
+func listedShortCodes(t *testing.T, s *testServer, token string) []string {
+	t.Helper()
+	w := s.do(http.MethodGet, "/api/v1/links", token, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/links status = %d (%s)", w.Code, w.Body)
+	}
+	var body struct {
+		Links []struct {
+			ShortCode string `json:"short_code"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	var codes []string
+	for _, link := range body.Links {
+		codes = append(codes, link.ShortCode)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+func TestOtherOwnersLinksAreNotFound(t *testing.T) {
+	s := newTestServer(t)
+	alice, bob := s.createUser(t, "alice"), s.createUser(t, "bob")
+	s.createLink(t, "alice1", alice)
+	s.createLink(t, "bob1", bob)
+	s.createLink(t, "legacy", nil)
+	allScopes := []string{models.ScopeLinksWrite, models.ScopeLinksRead, models.ScopeStatsRead}
+	aliceKey := s.createKey(t, alice, allScopes...)
+	bobKey := s.createKey(t, bob, allScopes...)
+	adminKey := s.createKey(t, nil, models.ScopeAdmin)
+
+	// Les liens d'un autre utilisateur, comme ceux sans propriétaire, répondent comme s'ils n'existaient pas.
+	for _, shortCode := range []string{"alice1", "legacy"} {
+		requests := []struct {
+			method string
+			path   string
+			body   string
+		}{
+			{http.MethodGet, "/api/v1/links/" + shortCode + "/stats", ""},
+			{http.MethodGet, "/api/v1/links/" + shortCode + "/stats/timeseries", ""},
+			{http.MethodGet, "/api/v1/links/" + shortCode + "/referrers", ""},
+			{http.MethodGet, "/api/v1/links/" + shortCode + "/health", ""},
+			{http.MethodPatch, "/api/v1/links/" + shortCode, `{"disabled":true}`},
+			{http.MethodDelete, "/api/v1/links/" + shortCode, ""},
+			{http.MethodPost, "/api/v1/links/" + shortCode + "/restore", ""},
+		}
+		for _, r := range requests {
+			if w := s.do(r.method, r.path, bobKey, r.body); w.Code != http.StatusNotFound {
+				t.Errorf("bob: %s %s status = %d, want %d (%s)", r.method, r.path, w.Code, http.StatusNotFound, w.Body)
+			}
+		}
+	}
+
+	// Le lien n'a pas été modifié par les requêtes refusées.
+	link, err := s.links.GetLinkByShortCode("alice1")
+	if err != nil {
+		t.Fatalf("alice1 after bob's requests: %v", err)
+	}
+	if link.Disabled {
+		t.Error("alice1 was disabled by another user")
+	}
+
+	if w := s.do(http.MethodGet, "/api/v1/links/alice1/stats", aliceKey, ""); w.Code != http.StatusOK {
+		t.Errorf("alice: GET /api/v1/links/alice1/stats status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := s.do(http.MethodGet, "/api/v1/links/legacy/stats", adminKey, ""); w.Code != http.StatusOK {
+		t.Errorf("admin: GET /api/v1/links/legacy/stats status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Chaque utilisateur ne liste que ses liens ; l'administrateur les voit tous.
+	if got, want := listedShortCodes(t, s, aliceKey), []string{"alice1"}; !slices.Equal(got, want) {
+		t.Errorf("alice lists %v, want %v", got, want)
+	}
+	if got, want := listedShortCodes(t, s, bobKey), []string{"bob1"}; !slices.Equal(got, want) {
+		t.Errorf("bob lists %v, want %v", got, want)
+	}
+	if got, want := listedShortCodes(t, s, adminKey), []string{"alice1", "bob1", "legacy"}; !slices.Equal(got, want) {
+		t.Errorf("admin lists %v, want %v", got, want)
+	}
+}
+
+func TestCreatedLinksBelongToTheKeyUser(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.createUser(t, "alice")
+	aliceKey := s.createKey(t, alice, models.ScopeLinksWrite)
+
+	w := s.do(http.MethodPost, "/api/v1/links", aliceKey, `{"long_url":"https://example.com/new","alias":"new"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/links status = %d (%s)", w.Code, w.Body)
+	}
+	link, err := s.links.GetLinkByShortCode("new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.OwnerID == nil || *link.OwnerID != *alice {
+		t.Errorf("created link owner = %v, want %d", link.OwnerID, *alice)
+	}
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultStatsWindow est la période analysée lorsque les paramètres from/to sont absents.
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+// GetLinkReferrersHandler retourne les domaines référents les plus fréquents d'un lien
+// (GET /api/v1/links/:shortCode/referrers?from=&to=&limit=).
+func GetLinkReferrersHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, ok := lookupStatsLink(c, linkService)
+		if !ok {
+			return
+		}
+
+		filter, truncated, ok := parseBreakdownWindow(c, clickService)
+		if !ok {
+			return
+		}
+
+		limit := 10
+		if raw := c.Query("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+				return
+			}
+			limit = n
+		}
+
+		referrers, err := clickService.GetTopReferrers(link.ID, filter, limit)
+		if err != nil {
+			requestLogger(c).Error("Error retrieving referrers", "short_code", link.Shortcode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(referrers))
+		for _, r := range referrers {
+			items = append(items, gin.H{"domain": r.Domain, "clicks": r.Clicks})
+		}
+
+		response := gin.H{
+			"short_code": link.Shortcode,
+			"from":       filter.From,
+			"to":         filter.To,
+			"referrers":  items,
+		}
+		markTruncatedByRetention(response, truncated)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetLinkTimeSeriesHandler retourne l'évolution des clics d'un lien par intervalle
+// (GET /api/v1/links/:shortCode/stats/timeseries?from=&to=&interval=hour|day|week&tz=Europe/Paris).
+// Les intervalles sans clic sont présents avec une valeur nulle.
+func GetLinkTimeSeriesHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, ok := lookupStatsLink(c, linkService)
+		if !ok {
+			return
+		}
+
+		loc := time.UTC
+		if tz := c.Query("tz"); tz != "" {
+			l, err := time.LoadLocation(tz)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown timezone: " + tz})
+				return
+			}
+			loc = l
+		}
+
+		filter, ok := parseStatsWindow(c, loc)
+		if !ok {
+			return
+		}
+
+		interval := c.DefaultQuery("interval", services.IntervalDay)
+
+		buckets, err := clickService.GetClickTimeSeries(link.ID, filter, interval, loc)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidStatsParams) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			requestLogger(c).Error("Error retrieving time series", "short_code", link.Shortcode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		total := 0
+		items := make([]gin.H, 0, len(buckets))
+		for _, b := range buckets {
+			total += b.Clicks
+			items = append(items, gin.H{"start": b.Start, "clicks": b.Clicks})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.Shortcode,
+			"interval":   interval,
+			"timezone":   loc.String(),
+			"from":       filter.From.In(loc),
+			"to":         filter.To.In(loc),
+			"total":      total,
+			"buckets":    items,
+		})
+	}
+}
+
+// GetLinkDevicesHandler retourne la répartition des clics d'un lien par navigateur, système et type d'appareil
+// (GET /api/v1/links/:shortCode/stats/devices?from=&to=&include_bots=).
+func GetLinkDevicesHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, ok := lookupStatsLink(c, linkService)
+		if !ok {
+			return
+		}
+
+		filter, truncated, ok := parseBreakdownWindow(c, clickService)
+		if !ok {
+			return
+		}
+
+		breakdown, err := clickService.GetDeviceBreakdown(link.ID, filter)
+		if err != nil {
+			requestLogger(c).Error("Error retrieving device breakdown", "short_code", link.Shortcode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		response := gin.H{
+			"short_code":   link.Shortcode,
+			"from":         filter.From,
+			"to":           filter.To,
+			"include_bots": filter.IncludeBots,
+			"bot_clicks":   breakdown.BotClicks,
+			"browsers":     dimensionItems(breakdown.Browsers),
+			"os":           dimensionItems(breakdown.OS),
+			"devices":      dimensionItems(breakdown.DeviceTypes),
+		}
+		markTruncatedByRetention(response, truncated)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetLinkGeoHandler retourne la répartition géographique des clics d'un lien
+// (GET /api/v1/links/:shortCode/stats/geo?from=&to=&country=).
+// Avec le paramètre country (code ISO), le détail par région et par ville de ce pays est ajouté.
+func GetLinkGeoHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, ok := lookupStatsLink(c, linkService)
+		if !ok {
+			return
+		}
+
+		filter, truncated, ok := parseBreakdownWindow(c, clickService)
+		if !ok {
+			return
+		}
+		filter.Country = strings.ToUpper(c.Query("country"))
+
+		breakdown, err := clickService.GetGeoBreakdown(link.ID, filter)
+		if err != nil {
+			requestLogger(c).Error("Error retrieving geo breakdown", "short_code", link.Shortcode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		response := gin.H{
+			"short_code": link.Shortcode,
+			"from":       filter.From,
+			"to":         filter.To,
+			"countries":  dimensionItems(breakdown.Countries),
+		}
+		if filter.Country != "" {
+			response["country"] = filter.Country
+			response["regions"] = dimensionItems(breakdown.Regions)
+			response["cities"] = dimensionItems(breakdown.Cities)
+		}
+		markTruncatedByRetention(response, truncated)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// dimensionItems convertit une répartition en liste JSON de paires nom / nombre de clics.
+func dimensionItems(counts []repository.DimensionCount) []gin.H {
+	items := make([]gin.H, 0, len(counts))
+	for _, count := range counts {
+		items = append(items, gin.H{"name": count.Value, "clicks": count.Clicks})
+	}
+	return items
+}
+
+// lookupStatsLink récupère le lien visé par une route de statistiques.
+// En cas d'échec, la réponse d'erreur est déjà écrite et ok vaut false.
+func lookupStatsLink(c *gin.Context, linkService *services.LinkService) (*models.Link, bool) {
+	shortCode := c.Param("shortCode")
+
+	link, err := linkService.GetLinkForStats(principalFrom(c), shortCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "link not found"})
+			return nil, false
+		}
+		requestLogger(c).Error("Error retrieving link for stats", "short_code", shortCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return nil, false
+	}
+	return link, true
+}
+
+// parseStatsWindow lit les paramètres from/to et include_bots d'une route de statistiques.
+// Par défaut, la période couvre les 30 derniers jours. Les dates sans heure sont interprétées dans loc.
+// En cas d'erreur, la réponse 400 est déjà écrite et ok vaut false.
+func parseStatsWindow(c *gin.Context, loc *time.Location) (repository.ClickFilter, bool) {
+	filter := repository.ClickFilter{}
+
+	includeBots, ok := parseIncludeBots(c)
+	if !ok {
+		return filter, false
+	}
+	filter.IncludeBots = includeBots
+
+	filter.From, filter.To, ok = parsePeriod(c, loc)
+	return filter, ok
+}
+
+// parseBreakdownWindow lit la période d'une route de répartition (référents, appareils, pays)
+// et la restreint aux clics bruts encore conservés ; truncated indique si son début a été avancé.
+// En cas d'erreur, la réponse 400 est déjà écrite et ok vaut false.
+func parseBreakdownWindow(c *gin.Context, clickService *services.ClickService) (filter repository.ClickFilter, truncated, ok bool) {
+	filter, ok = parseStatsWindow(c, time.UTC)
+	if !ok {
+		return filter, false, false
+	}
+	filter, truncated = clickService.BreakdownWindow(filter)
+	return filter, truncated, true
+}
+
+// markTruncatedByRetention signale dans une réponse de répartition que la période demandée
+// commençait avant la limite de rétention : "from" est alors la limite, pas la date demandée.
+func markTruncatedByRetention(response gin.H, truncated bool) {
+	if truncated {
+		response["truncated_by_retention"] = true
+	}
+}
+
+// parsePeriod lit les paramètres from/to d'une route de statistiques (30 derniers jours par défaut).
+// Les dates sans heure sont interprétées dans loc.
+// En cas d'erreur, la réponse 400 est déjà écrite et ok vaut false.
+func parsePeriod(c *gin.Context, loc *time.Location) (from, to time.Time, ok bool) {
+	to = time.Now()
+	if raw := c.Query("to"); raw != "" {
+		t, err := parseTimeParam(raw, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error()})
+			return from, to, false
+		}
+		to = t
+	}
+
+	from = to.Add(-defaultStatsWindow)
+	if raw := c.Query("from"); raw != "" {
+		t, err := parseTimeParam(raw, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error()})
+			return from, to, false
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// parseIncludeBots lit le paramètre include_bots (false par défaut).
+// En cas d'erreur, la réponse 400 est déjà écrite et ok vaut false.
+func parseIncludeBots(c *gin.Context) (bool, bool) {
+	raw := c.Query("include_bots")
+	if raw == "" {
+		return false, true
+	}
+	includeBots, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include_bots must be a boolean"})
+		return false, false
+	}
+	return includeBots, true
+}
@@ -0,0 +1,168 @@
+// Package blocklist charge des listes locales de destinations malveillantes (noms d'hôte et préfixes d'URL)
+// et les compare aux URLs des liens, à la création comme lors des analyses périodiques des liens existants.
+package blocklist
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+// ignoredHosts sont les noms présents dans tout fichier hosts, qui ne désignent pas une destination bloquée.
+var ignoredHosts = map[string]struct{}{
+	"localhost":             {},
+	"localhost.localdomain": {},
+	"local":                 {},
+	"broadcasthost":         {},
+	"ip6-localhost":         {},
+	"ip6-loopback":          {},
+	"0.0.0.0":               {},
+}
+
+// Blocklist est l'union de plusieurs fichiers de liste de blocage, rechargés lorsqu'ils changent.
+// Deux formats sont acceptés, ligne par ligne (les lignes vides et ce qui suit un '#' sont ignorés) :
+//   - format hosts : une adresse suivie d'un ou plusieurs noms d'hôte ("0.0.0.0 phishing.example") ;
+//   - liste simple : un nom d'hôte ("phishing.example") ou un préfixe d'URL ("https://sites.example/view/phish").
+//
+// Un nom d'hôte bloque aussi ses sous-domaines. Elle est sûre pour un usage concurrent.
+type Blocklist struct {
+	paths []string
+
+	mu       sync.RWMutex
+	hosts    map[string]struct{} // Noms d'hôte en ASCII minuscule
+	prefixes []string            // Préfixes d'URL en minuscules
+	modTimes map[string]time.Time
+}
+
+// New charge les fichiers donnés. Une erreur est retournée si l'un d'eux est illisible.
+func New(paths []string) (*Blocklist, error) {
+	b := &Blocklist{paths: paths}
+	if _, err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Reload relit les fichiers si l'un d'eux a été modifié depuis le dernier chargement
+// et indique si la liste a été rechargée. En cas d'erreur, la liste précédente reste en vigueur.
+func (b *Blocklist) Reload() (bool, error) {
+	modTimes := make(map[string]time.Time, len(b.paths))
+	changed := b.modTimes == nil
+	for _, path := range b.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read blocklist %s: %w", path, err)
+		}
+		modTimes[path] = info.ModTime()
+		if !info.ModTime().Equal(b.modTimes[path]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	hosts := make(map[string]struct{})
+	var prefixes []string
+	for _, path := range b.paths {
+		if err := parseFile(path, hosts, &prefixes); err != nil {
+			return false, err
+		}
+	}
+
+	b.mu.Lock()
+	b.hosts, b.prefixes, b.modTimes = hosts, prefixes, modTimes
+	b.mu.Unlock()
+	logger().Info("Blocklist loaded", "files", len(b.paths), "hosts", len(hosts), "url_prefixes", len(prefixes))
+	return true, nil
+}
+
+// parseFile ajoute les entrées d'un fichier de liste de blocage à hosts et prefixes.
+func parseFile(path string, hosts map[string]struct{}, prefixes *[]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read blocklist %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// Format hosts : l'adresse de redirection (0.0.0.0, 127.0.0.1...) précède les noms bloqués.
+		if _, err := netip.ParseAddr(fields[0]); err == nil && len(fields) > 1 {
+			fields = fields[1:]
+		}
+		for _, entry := range fields {
+			if strings.Contains(entry, "://") {
+				*prefixes = append(*prefixes, strings.ToLower(entry))
+				continue
+			}
+			if host := normalizeHost(entry); host != "" {
+				if _, ignored := ignoredHosts[host]; !ignored {
+					hosts[host] = struct{}{}
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read blocklist %s: %w", path, err)
+	}
+	return nil
+}
+
+// Match indique si une URL figure dans la liste et retourne l'entrée correspondante
+// (nom d'hôte ou préfixe d'URL), qui sert de motif de quarantaine.
+func (b *Blocklist) Match(rawURL string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	lower := strings.ToLower(rawURL)
+	for _, prefix := range b.prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return prefix, true
+		}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	// Le nom d'hôte et chacun de ses domaines parents sont recherchés : "a.b.phishing.example" est bloqué par "phishing.example".
+	for host := normalizeHost(u.Hostname()); host != ""; {
+		if _, blocked := b.hosts[host]; blocked {
+			return host, true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return "", false
+}
+
+// normalizeHost met un nom d'hôte sous sa forme ASCII (punycode) en minuscules, sans point final.
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		return ascii
+	}
+	return host
+}
+
+// logger retourne le logger de la liste de blocage, dérivé du logger par défaut au moment de l'appel.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "blocklist")
+}
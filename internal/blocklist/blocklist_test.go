@@ -0,0 +1,116 @@
+package blocklist
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeList écrit une liste de blocage dans dir et retourne son chemin.
+func writeList(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// silenceLogs coupe les logs de la liste de blocage pendant le test.
+func silenceLogs(t *testing.T) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+}
+
+func TestBlocklistParsesHostsAndPlainLists(t *testing.T) {
+	silenceLogs(t)
+	dir := t.TempDir()
+	hostsFile := writeList(t, dir, "hosts", `# Liste au format hosts
+127.0.0.1 localhost
+::1 ip6-localhost ip6-loopback
+0.0.0.0 0.0.0.0
+0.0.0.0 phishing.example   malware.example # deux noms sur une ligne
+0.0.0.0 Tracker.Example.
+`)
+	plainFile := writeList(t, dir, "plain.txt", `
+scam.example
+bücher-gratuit.example
+https://Sites.Example/view/phish   # préfixe d'URL
+`)
+
+	list, err := New([]string{hostsFile, plainFile})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		url       string
+		wantEntry string // Entrée attendue ("" si l'URL n'est pas bloquée)
+	}{
+		{"https://phishing.example/login", "phishing.example"},
+		{"http://malware.example", "malware.example"},
+		{"https://cdn.a.phishing.example/x.js", "phishing.example"},
+		{"https://tracker.example/pixel", "tracker.example"},
+		{"https://SCAM.example/", "scam.example"},
+		{"https://bücher-gratuit.example/", "xn--bcher-gratuit-wob.example"},
+		{"https://sites.example/view/phish?id=1", "https://sites.example/view/phish"},
+		{"https://sites.example/view/legit", ""},
+		{"https://notphishing.example/", ""},
+		{"https://example.com/", ""},
+		// Les noms standard d'un fichier hosts ne bloquent rien.
+		{"http://localhost:8080/", ""},
+		{"http://ip6-localhost/", ""},
+	}
+	for _, tt := range tests {
+		entry, blocked := list.Match(tt.url)
+		if blocked != (tt.wantEntry != "") || entry != tt.wantEntry {
+			t.Errorf("Match(%q) = %q, %v; want %q", tt.url, entry, blocked, tt.wantEntry)
+		}
+	}
+}
+
+func TestBlocklistReloadsOnModTimeChange(t *testing.T) {
+	silenceLogs(t)
+	path := writeList(t, t.TempDir(), "blocklist.txt", "first.example\n")
+	list, err := New([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fichier inchangé : aucune relecture.
+	if reloaded, err := list.Reload(); err != nil || reloaded {
+		t.Fatalf("Reload() of unchanged file = %v, %v; want false, nil", reloaded, err)
+	}
+
+	// Nouveau contenu avec une date de modification différente : la liste est remplacée.
+	if err := os.WriteFile(path, []byte("second.example\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := list.Reload(); err != nil || !reloaded {
+		t.Fatalf("Reload() of modified file = %v, %v; want true, nil", reloaded, err)
+	}
+	if _, blocked := list.Match("https://first.example/"); blocked {
+		t.Error("first.example still blocked after reload")
+	}
+	if _, blocked := list.Match("https://second.example/"); !blocked {
+		t.Error("second.example not blocked after reload")
+	}
+
+	// Un fichier devenu illisible laisse la liste précédente en vigueur.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.Reload(); err == nil {
+		t.Error("Reload() of missing file = nil error, want an error")
+	}
+	if _, blocked := list.Match("https://second.example/"); !blocked {
+		t.Error("second.example no longer blocked after a failed reload")
+	}
+}
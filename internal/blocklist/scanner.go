@@ -0,0 +1,120 @@
+package blocklist
+
+import (
+	"context"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Valeurs par défaut du Scanner.
+const (
+	DefaultReloadInterval = 30 * time.Second
+	DefaultScanInterval   = time.Hour
+)
+
+// Scanner recharge la liste de blocage lorsque ses fichiers changent et met en quarantaine les liens existants
+// dont l'URL longue ou l'URL de repli y figure. Une analyse complète a lieu au démarrage, à chaque rechargement
+// et toutes les scanInterval. Les liens libérés par un administrateur ne sont plus remis en quarantaine.
+type Scanner struct {
+	blocklist      *Blocklist
+	linkRepo       repository.LinkRepository
+	metrics        *metrics.Metrics
+	reloadInterval time.Duration
+	scanInterval   time.Duration
+}
+
+// NewScanner crée un Scanner. Les intervalles nuls ou négatifs sont remplacés par les valeurs par défaut.
+// Les mises en quarantaine sont comptées dans m (nil = non mesurées).
+func NewScanner(blocklist *Blocklist, linkRepo repository.LinkRepository, m *metrics.Metrics, reloadInterval, scanInterval time.Duration) *Scanner {
+	if reloadInterval <= 0 {
+		reloadInterval = DefaultReloadInterval
+	}
+	if scanInterval <= 0 {
+		scanInterval = DefaultScanInterval
+	}
+	return &Scanner{
+		blocklist:      blocklist,
+		linkRepo:       linkRepo,
+		metrics:        m,
+		reloadInterval: reloadInterval,
+		scanInterval:   scanInterval,
+	}
+}
+
+// Start lance la boucle de rechargement et d'analyse jusqu'à l'annulation de ctx.
+func (s *Scanner) Start(ctx context.Context) {
+	logger().Info("Starting blocklist scanner",
+		"reload_interval", s.reloadInterval.String(), "scan_interval", s.scanInterval.String())
+	reload := time.NewTicker(s.reloadInterval)
+	defer reload.Stop()
+	scan := time.NewTicker(s.scanInterval)
+	defer scan.Stop()
+
+	s.scan(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload.C:
+			reloaded, err := s.blocklist.Reload()
+			if err != nil {
+				logger().Error("Failed to reload blocklist, keeping previous entries", "error", err)
+				continue
+			}
+			if reloaded {
+				s.scan(ctx)
+			}
+		case <-scan.C:
+			s.scan(ctx)
+		}
+	}
+}
+
+// scan met en quarantaine les liens dont une destination figure dans la liste de blocage.
+func (s *Scanner) scan(ctx context.Context) {
+	links, err := s.linkRepo.GetAllLinks()
+	if err != nil {
+		logger().Error("Failed to fetch links to scan", "error", err)
+		return
+	}
+
+	quarantined := 0
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return
+		}
+		if link.Quarantined || link.QuarantineOverride {
+			continue
+		}
+		entry, blocked := s.matchLink(link)
+		if !blocked {
+			continue
+		}
+
+		done, err := s.linkRepo.QuarantineLink(link.ID, entry, time.Now())
+		if err != nil {
+			logger().Error("Failed to quarantine link", "short_code", link.Shortcode, "error", err)
+			continue
+		}
+		if done {
+			quarantined++
+			s.metrics.LinkQuarantined()
+			logger().Warn("Link quarantined", "short_code", link.Shortcode, "long_url", link.LongURL, "blocklist_entry", entry)
+		}
+	}
+	logger().Debug("Blocklist scan completed", "links", len(links), "quarantined", quarantined)
+}
+
+// matchLink compare l'URL longue puis l'URL de repli d'un lien à la liste de blocage.
+func (s *Scanner) matchLink(link models.Link) (string, bool) {
+	if entry, blocked := s.blocklist.Match(link.LongURL); blocked {
+		return entry, true
+	}
+	if link.FallbackURL != "" {
+		return s.blocklist.Match(link.FallbackURL)
+	}
+	return "", false
+}
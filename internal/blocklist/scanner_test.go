@@ -0,0 +1,71 @@
+package blocklist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+)
+
+func TestScanQuarantinesBlockedLinks(t *testing.T) {
+	silenceLogs(t)
+	path := writeList(t, t.TempDir(), "blocklist.txt", "0.0.0.0 phishing.example\nhttps://sites.example/view/phish\n")
+	list, err := New([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	linkRepo := repository.NewLinkRepository(repositorytest.OpenDatabase(t))
+	links := []*models.Link{
+		{Shortcode: "clean", LongURL: "https://example.com/"},
+		{Shortcode: "blocked", LongURL: "https://login.phishing.example/"},
+		{Shortcode: "prefix", LongURL: "https://sites.example/view/phish/page"},
+		{Shortcode: "fallback", LongURL: "https://example.com/", FallbackURL: "https://phishing.example/offer"},
+		{Shortcode: "disabled", LongURL: "https://phishing.example/old", Disabled: true},
+		// Libéré par un administrateur après examen : il n'est plus remis en quarantaine.
+		{Shortcode: "released", LongURL: "https://phishing.example/reviewed", QuarantineOverride: true},
+	}
+	for _, link := range links {
+		if err := linkRepo.CreateLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewScanner(list, linkRepo, nil, time.Hour, time.Hour)
+	scanner.scan(context.Background())
+
+	want := map[string]string{
+		"clean":    "",
+		"blocked":  "phishing.example",
+		"prefix":   "https://sites.example/view/phish",
+		"fallback": "phishing.example",
+		"disabled": "phishing.example",
+		"released": "",
+	}
+	for shortCode, wantReason := range want {
+		link, err := linkRepo.GetLinkByShortCode(shortCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.Quarantined != (wantReason != "") || link.QuarantineReason != wantReason {
+			t.Errorf("%s: quarantined = %v (reason %q), want reason %q", shortCode, link.Quarantined, link.QuarantineReason, wantReason)
+		}
+	}
+
+	// Une seconde analyse ne modifie pas les quarantaines déjà posées.
+	before, err := linkRepo.GetLinkByShortCode("blocked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner.scan(context.Background())
+	after, err := linkRepo.GetLinkByShortCode("blocked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.QuarantinedAt == nil || after.QuarantinedAt == nil || !after.QuarantinedAt.Equal(*before.QuarantinedAt) {
+		t.Errorf("QuarantinedAt changed on rescan: %v -> %v", before.QuarantinedAt, after.QuarantinedAt)
+	}
+}
@@ -8,18 +8,76 @@ import (
 
 type Config struct {
 	Server struct {
-		Port    int    `mapstructure:"port"`
-		BaseURL string `mapstructure:"base_url"`
+		Port                   int    `mapstructure:"port"`
+		BaseURL                string `mapstructure:"base_url"`
+		ExpiredFallbackURL     string `mapstructure:"expired_fallback_url"`
+		ShutdownTimeoutSeconds int    `mapstructure:"shutdown_timeout_seconds"`
 	} `mapstructure:"server"`
 	Database struct {
 		Name string `mapstructure:"name"`
 	} `mapstructure:"database"`
 	Analytics struct {
-		BufferSize int `mapstructure:"buffer_size"`
+		BufferSize      int    `mapstructure:"buffer_size"`
+		WorkerCount     int    `mapstructure:"worker_count"`      // Nombre de workers écrivant les clics en base
+		IPMode          string `mapstructure:"ip_mode"`           // full, truncate, hash ou none
+		IPHashSecret    string `mapstructure:"ip_hash_secret"`    // Clé HMAC du mode hash (aléatoire au démarrage si vide)
+		RetentionDays   int    `mapstructure:"retention_days"`    // Durée de conservation des clics bruts (0 = illimitée)
+		PurgeBatchSize  int    `mapstructure:"purge_batch_size"`  // Nombre de clics agrégés par transaction de purge
+		BatchSize       int    `mapstructure:"batch_size"`        // Nombre maximal de clics insérés par transaction
+		FlushIntervalMs int    `mapstructure:"flush_interval_ms"` // Délai maximal avant l'écriture d'un lot incomplet
 	} `mapstructure:"analytics"`
 	Monitor struct {
-		IntervalMinutes int `mapstructure:"interval_minutes"`
+		IntervalMinutes    int      `mapstructure:"interval_minutes"`
+		HistoryDays        int      `mapstructure:"history_days"`         // Durée de conservation des vérifications (0 = illimitée)
+		Workers            int      `mapstructure:"workers"`              // Nombre maximal de vérifications simultanées
+		PerHostConcurrency int      `mapstructure:"per_host_concurrency"` // Vérifications simultanées maximales vers un même hôte
+		PerHostIntervalMs  int      `mapstructure:"per_host_interval_ms"` // Délai minimal entre deux vérifications d'un même hôte
+		TimeoutSeconds     int      `mapstructure:"timeout_seconds"`      // Délai maximal d'une vérification
+		Jitter             bool     `mapstructure:"jitter"`               // Répartit les vérifications sur l'intervalle
+		MaxRedirects       int      `mapstructure:"max_redirects"`        // Redirections suivies au plus par vérification
+		Soft404Patterns    []string `mapstructure:"soft_404_patterns"`    // Motifs signalant une page 2xx introuvable ou parquée
+		FailoverThreshold  int      `mapstructure:"failover_threshold"`   // Échecs consécutifs avant de rediriger vers l'URL de repli
+		Notifications      struct {
+			FailureThreshold int `mapstructure:"failure_threshold"` // Échecs consécutifs avant d'alerter
+			Webhooks         []struct {
+				URL    string `mapstructure:"url"`
+				Secret string `mapstructure:"secret"` // Clé HMAC de signature des requêtes (vide = non signées)
+			} `mapstructure:"webhooks"`
+			WebhookRetries int `mapstructure:"webhook_retries"` // Nouvelles tentatives après un échec d'envoi
+			SMTP           struct {
+				Host     string   `mapstructure:"host"` // Vide = e-mails désactivés
+				Port     int      `mapstructure:"port"`
+				Username string   `mapstructure:"username"`
+				Password string   `mapstructure:"password"`
+				From     string   `mapstructure:"from"`
+				To       []string `mapstructure:"to"`
+			} `mapstructure:"smtp"`
+		} `mapstructure:"notifications"`
 	} `mapstructure:"monitor"`
+	URLPolicy struct {
+		AllowedSchemes       []string `mapstructure:"allowed_schemes"`        // Schémas acceptés pour les destinations
+		MaxLength            int      `mapstructure:"max_length"`             // Longueur maximale d'une URL de destination
+		AllowedDomains       []string `mapstructure:"allowed_domains"`        // Domaines autorisés (vide = tous)
+		DeniedDomains        []string `mapstructure:"denied_domains"`         // Domaines refusés
+		AllowPrivateNetworks bool     `mapstructure:"allow_private_networks"` // Autorise localhost et les réseaux privés (développement)
+	} `mapstructure:"url_policy"`
+	Blocklist struct {
+		Files                 []string `mapstructure:"files"`                   // Fichiers de liste de blocage (format hosts ou liste simple)
+		ReloadIntervalSeconds int      `mapstructure:"reload_interval_seconds"` // Intervalle de détection des modifications des fichiers
+		ScanIntervalMinutes   int      `mapstructure:"scan_interval_minutes"`   // Intervalle entre deux analyses des liens existants
+	} `mapstructure:"blocklist"`
+	GeoIP struct {
+		DatabasePath string `mapstructure:"database_path"`
+	} `mapstructure:"geoip"`
+	Journal struct {
+		Dir            string `mapstructure:"dir"`              // Dossier du journal des clics (vide = désactivé)
+		SegmentSizeMB  int    `mapstructure:"segment_size_mb"`  // Taille maximale d'un segment
+		SyncIntervalMs int    `mapstructure:"sync_interval_ms"` // Intervalle entre deux fsync
+	} `mapstructure:"journal"`
+	Logging struct {
+		Level  string `mapstructure:"level"`  // debug, info, warn ou error
+		Format string `mapstructure:"format"` // text ou json
+	} `mapstructure:"logging"`
 }
 
 // LoadConfig charge la configuration de l'application en utilisant Viper.
@@ -41,9 +99,44 @@ func LoadConfig() (*Config, error) {
 	// ou si le fichier n'existe pas.
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.expired_fallback_url", "")
+	viper.SetDefault("server.shutdown_timeout_seconds", 15)
 	viper.SetDefault("database.name", "urlshortener.db")
 	viper.SetDefault("analytics.buffer_size", 100)
+	viper.SetDefault("analytics.worker_count", 2)
+	viper.SetDefault("analytics.ip_mode", "full")
+	viper.SetDefault("analytics.ip_hash_secret", "")
+	viper.SetDefault("analytics.retention_days", 0)
+	viper.SetDefault("analytics.purge_batch_size", 1000)
+	viper.SetDefault("analytics.batch_size", 100)
+	viper.SetDefault("analytics.flush_interval_ms", 500)
 	viper.SetDefault("monitor.interval_minutes", 60)
+	viper.SetDefault("monitor.history_days", 30)
+	viper.SetDefault("monitor.workers", 20)
+	viper.SetDefault("monitor.per_host_concurrency", 2)
+	viper.SetDefault("monitor.per_host_interval_ms", 1000)
+	viper.SetDefault("monitor.timeout_seconds", 5)
+	viper.SetDefault("monitor.jitter", true)
+	viper.SetDefault("monitor.max_redirects", 10)
+	viper.SetDefault("monitor.soft_404_patterns", []string{})
+	viper.SetDefault("monitor.failover_threshold", 3)
+	viper.SetDefault("monitor.notifications.failure_threshold", 3)
+	viper.SetDefault("monitor.notifications.webhook_retries", 3)
+	viper.SetDefault("monitor.notifications.smtp.port", 587)
+	viper.SetDefault("url_policy.allowed_schemes", []string{"http", "https"})
+	viper.SetDefault("url_policy.max_length", 2048)
+	viper.SetDefault("url_policy.allowed_domains", []string{})
+	viper.SetDefault("url_policy.denied_domains", []string{})
+	viper.SetDefault("url_policy.allow_private_networks", false)
+	viper.SetDefault("blocklist.files", []string{})
+	viper.SetDefault("blocklist.reload_interval_seconds", 30)
+	viper.SetDefault("blocklist.scan_interval_minutes", 60)
+	viper.SetDefault("geoip.database_path", "")
+	viper.SetDefault("journal.dir", "")
+	viper.SetDefault("journal.segment_size_mb", 16)
+	viper.SetDefault("journal.sync_interval_ms", 50)
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "text")
 
 	// Lire le fichier de configuration.
 	if err := viper.ReadInConfig(); err != nil {
@@ -57,9 +150,5 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	// Log  pour vérifier la config chargée
-	log.Printf("Configuration loaded: Server Port=%d, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
-
 	return &cfg, nil // Retourne la configuration chargée
 }
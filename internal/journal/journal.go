@@ -0,0 +1,401 @@
+// Package journal implémente un journal local append-only, découpé en segments,
+// qui conserve des enregistrements jusqu'à leur acquittement.
+//
+// Chaque enregistrement reçoit un numéro de séquence croissant. Les écritures sont
+// synchronisées sur disque (fsync) par lots, à intervalle régulier. Les numéros acquittés
+// sont mémorisés dans un fichier d'état ; les segments entièrement acquittés sont supprimés
+// et les enregistrements non acquittés sont relus après un redémarrage.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Valeurs par défaut des options du journal.
+const (
+	DefaultSegmentSize  = 16 << 20 // 16 Mio
+	DefaultSyncInterval = 50 * time.Millisecond
+)
+
+// stateFile est le nom du fichier d'état (acquittements) dans le dossier du journal.
+const stateFile = "state.json"
+
+// ErrClosed est retournée par les opérations effectuées sur un journal fermé.
+var ErrClosed = errors.New("journal is closed")
+
+// Options configure un journal.
+type Options struct {
+	Dir          string        // Dossier du journal, créé si nécessaire
+	SegmentSize  int64         // Taille au-delà de laquelle un nouveau segment est ouvert
+	SyncInterval time.Duration // Intervalle maximal entre une écriture et sa synchronisation sur disque
+}
+
+// state est le contenu persistant du fichier d'état.
+// Tous les numéros inférieurs ou égaux à Watermark sont acquittés ; Acked contient
+// les numéros supérieurs acquittés dans le désordre (plusieurs workers en parallèle).
+type state struct {
+	Watermark uint64   `json:"watermark"`
+	Acked     []uint64 `json:"acked,omitempty"`
+}
+
+// Journal est un journal append-only sûr pour un usage concurrent.
+type Journal struct {
+	opts Options
+
+	mu         sync.Mutex
+	cond       *sync.Cond // Signalé à chaque ajout et à la fermeture
+	segments   []segment
+	active     *os.File
+	activeSize int64
+	lastSeq    uint64
+	watermark  uint64
+	acked      map[uint64]struct{}
+	dirty      bool // Écritures non synchronisées sur disque
+	stateDirty bool // Acquittements non persistés
+	sealed     bool // Plus aucun ajout accepté : les lecteurs s'arrêtent à la fin du journal
+	closed     bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open ouvre (ou crée) le journal situé dans opts.Dir.
+// Un enregistrement tronqué en fin de dernier segment (arrêt brutal) est ignoré et supprimé.
+func Open(opts Options) (*Journal, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("journal directory is required")
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = DefaultSyncInterval
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory %s: %w", opts.Dir, err)
+	}
+
+	j := &Journal{
+		opts:  opts,
+		acked: make(map[uint64]struct{}),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	j.cond = sync.NewCond(&j.mu)
+
+	if err := j.loadState(); err != nil {
+		return nil, err
+	}
+	if err := j.recover(); err != nil {
+		return nil, err
+	}
+	j.removeObsoleteSegmentsLocked()
+
+	go j.syncLoop()
+	return j, nil
+}
+
+// loadState lit le fichier d'état s'il existe.
+func (j *Journal) loadState() error {
+	data, err := os.ReadFile(filepath.Join(j.opts.Dir, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read journal state: %w", err)
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("failed to decode journal state: %w", err)
+	}
+	j.watermark = s.Watermark
+	for _, seq := range s.Acked {
+		if seq > j.watermark {
+			j.acked[seq] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// recover parcourt les segments existants pour retrouver le dernier numéro de séquence,
+// tronque un éventuel enregistrement incomplet en fin de journal et ouvre le segment actif.
+func (j *Journal) recover() error {
+	segments, err := listSegments(j.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to list journal segments: %w", err)
+	}
+
+	j.lastSeq = j.watermark
+	for i, seg := range segments {
+		lastSeq, validSize, corrupt, err := scanSegment(seg.path)
+		if err != nil {
+			return fmt.Errorf("failed to scan journal segment %s: %w", seg.path, err)
+		}
+		if corrupt {
+			if i != len(segments)-1 {
+				return fmt.Errorf("corrupt record in journal segment %s at offset %d", seg.path, validSize)
+			}
+			logger().Warn("Truncating incomplete record at end of journal segment", "segment", seg.path, "offset", validSize)
+			if err := os.Truncate(seg.path, validSize); err != nil {
+				return fmt.Errorf("failed to truncate journal segment %s: %w", seg.path, err)
+			}
+		}
+		if lastSeq > j.lastSeq {
+			j.lastSeq = lastSeq
+		}
+		if i == len(segments)-1 && validSize < j.opts.SegmentSize {
+			f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return fmt.Errorf("failed to open journal segment %s: %w", seg.path, err)
+			}
+			j.active, j.activeSize = f, validSize
+		}
+	}
+	j.segments = segments
+
+	if j.active == nil {
+		return j.openSegmentLocked(j.lastSeq + 1)
+	}
+	return nil
+}
+
+// openSegmentLocked crée un nouveau segment actif commençant à firstSeq.
+func (j *Journal) openSegmentLocked(firstSeq uint64) error {
+	path := segmentPath(j.opts.Dir, firstSeq)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create journal segment %s: %w", path, err)
+	}
+	j.active, j.activeSize = f, 0
+	j.segments = append(j.segments, segment{firstSeq: firstSeq, path: path})
+	return nil
+}
+
+// Append ajoute un enregistrement au journal et retourne son numéro de séquence.
+// L'enregistrement est écrit immédiatement mais n'est garanti sur disque qu'après
+// la synchronisation suivante (au plus SyncInterval plus tard).
+func (j *Journal) Append(data []byte) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed || j.sealed {
+		return 0, ErrClosed
+	}
+
+	seq := j.lastSeq + 1
+	record := encodeRecord(seq, data)
+
+	if j.activeSize > 0 && j.activeSize+int64(len(record)) > j.opts.SegmentSize {
+		if err := j.rollLocked(seq); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := j.active.Write(record)
+	if err != nil {
+		// Une écriture partielle est retirée pour ne pas laisser d'enregistrement tronqué au milieu du segment.
+		if n > 0 {
+			_ = j.active.Truncate(j.activeSize)
+		}
+		return 0, fmt.Errorf("failed to append to journal: %w", err)
+	}
+
+	j.activeSize += int64(n)
+	j.lastSeq = seq
+	j.dirty = true
+	j.cond.Broadcast()
+	return seq, nil
+}
+
+// rollLocked synchronise et ferme le segment actif puis en ouvre un nouveau commençant à firstSeq.
+func (j *Journal) rollLocked(firstSeq uint64) error {
+	if err := j.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal segment: %w", err)
+	}
+	if err := j.active.Close(); err != nil {
+		return fmt.Errorf("failed to close journal segment: %w", err)
+	}
+	return j.openSegmentLocked(firstSeq)
+}
+
+// Ack acquitte des enregistrements : ils ne seront plus relus et leurs segments pourront être supprimés.
+// Les acquittements sont persistés lors de la synchronisation suivante.
+func (j *Journal) Ack(seqs ...uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, seq := range seqs {
+		if seq > j.watermark {
+			j.acked[seq] = struct{}{}
+		}
+	}
+	for {
+		if _, ok := j.acked[j.watermark+1]; !ok {
+			break
+		}
+		delete(j.acked, j.watermark+1)
+		j.watermark++
+	}
+	j.stateDirty = true
+}
+
+// Pending retourne le nombre d'enregistrements écrits et non encore acquittés.
+func (j *Journal) Pending() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return int(j.lastSeq-j.watermark) - len(j.acked)
+}
+
+// isAckedLocked indique si un numéro de séquence a déjà été acquitté.
+func (j *Journal) isAckedLocked(seq uint64) bool {
+	if seq <= j.watermark {
+		return true
+	}
+	_, ok := j.acked[seq]
+	return ok
+}
+
+// syncLoop synchronise périodiquement le segment actif et l'état des acquittements.
+func (j *Journal) syncLoop() {
+	defer close(j.done)
+	ticker := time.NewTicker(j.opts.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+			if err := j.sync(); err != nil {
+				logger().Error("Failed to sync journal", "error", err)
+			}
+		}
+	}
+}
+
+// sync effectue un fsync du segment actif et persiste l'état si nécessaire.
+// Le fsync est fait hors du verrou pour ne pas bloquer les ajouts concurrents.
+func (j *Journal) sync() error {
+	j.mu.Lock()
+	active, dirty := j.active, j.dirty
+	j.dirty = false
+	var snapshot *state
+	if j.stateDirty {
+		snapshot = j.snapshotLocked()
+		j.stateDirty = false
+	}
+	j.mu.Unlock()
+
+	if dirty {
+		// Le segment a pu être fermé entre-temps par un changement de segment, qui l'a déjà synchronisé.
+		if err := active.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+			return fmt.Errorf("failed to sync journal segment: %w", err)
+		}
+	}
+	if snapshot != nil {
+		if err := j.writeState(snapshot); err != nil {
+			return err
+		}
+		j.mu.Lock()
+		j.removeObsoleteSegmentsLocked()
+		j.mu.Unlock()
+	}
+	return nil
+}
+
+// snapshotLocked copie l'état des acquittements pour l'écrire hors du verrou.
+func (j *Journal) snapshotLocked() *state {
+	s := &state{Watermark: j.watermark, Acked: make([]uint64, 0, len(j.acked))}
+	for seq := range j.acked {
+		s.Acked = append(s.Acked, seq)
+	}
+	sort.Slice(s.Acked, func(i, k int) bool { return s.Acked[i] < s.Acked[k] })
+	return s
+}
+
+// writeState remplace atomiquement le fichier d'état (écriture dans un fichier temporaire puis renommage).
+func (j *Journal) writeState(s *state) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal state: %w", err)
+	}
+
+	path := filepath.Join(j.opts.Dir, stateFile)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write journal state: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write journal state: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync journal state: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write journal state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace journal state: %w", err)
+	}
+	return nil
+}
+
+// removeObsoleteSegmentsLocked supprime les segments (hors segment actif) dont tous les enregistrements sont acquittés.
+func (j *Journal) removeObsoleteSegmentsLocked() {
+	for len(j.segments) > 1 && j.segments[1].firstSeq-1 <= j.watermark {
+		if err := os.Remove(j.segments[0].path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger().Error("Failed to remove journal segment", "segment", j.segments[0].path, "error", err)
+			return
+		}
+		j.segments = j.segments[1:]
+	}
+}
+
+// Seal refuse tout nouvel ajout : les lecteurs terminent la lecture des enregistrements déjà écrits
+// puis Next retourne io.EOF au lieu d'attendre. Utilisé lors de l'arrêt pour vider le journal.
+func (j *Journal) Seal() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.sealed = true
+	j.cond.Broadcast()
+}
+
+// Close synchronise le journal, persiste les acquittements et libère les lecteurs en attente.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return nil
+	}
+	j.closed = true
+	j.dirty = true
+	j.stateDirty = true
+	j.cond.Broadcast()
+	j.mu.Unlock()
+
+	close(j.stop)
+	<-j.done
+
+	// Les ajouts sont refusés une fois closed positionné : la dernière synchronisation est complète.
+	if err := j.sync(); err != nil {
+		return err
+	}
+	return j.active.Close()
+}
+
+// logger retourne le logger du journal, dérivé du logger par défaut au moment de l'appel.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "journal")
+}
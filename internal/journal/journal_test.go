@@ -0,0 +1,221 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// openTestJournal ouvre un journal dans dir, refermé à la fin du test s'il ne l'a pas été.
+func openTestJournal(t *testing.T, dir string, segmentSize int64) *Journal {
+	t.Helper()
+	j, err := Open(Options{Dir: dir, SegmentSize: segmentSize, SyncInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+// appendRecords ajoute n enregistrements "record-<seq>" et retourne leurs numéros de séquence.
+func appendRecords(t *testing.T, j *Journal, n int) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	for i := 0; i < n; i++ {
+		seq, err := j.Append([]byte(fmt.Sprintf("record-%d", j.lastSeq+1)))
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
+
+// readAll scelle le journal et retourne les numéros de séquence non acquittés, vérifiant leur contenu.
+func readAll(t *testing.T, j *Journal) []uint64 {
+	t.Helper()
+	j.Seal()
+	r := j.NewReader()
+	defer r.Close()
+
+	var seqs []uint64
+	for {
+		seq, data, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return seqs
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if want := fmt.Sprintf("record-%d", seq); string(data) != want {
+			t.Errorf("record %d = %q, want %q", seq, data, want)
+		}
+		seqs = append(seqs, seq)
+	}
+}
+
+// segmentFiles retourne les noms des segments présents dans dir.
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	segments, err := listSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range segments {
+		names = append(names, filepath.Base(s.path))
+	}
+	return names
+}
+
+// silenceLogs coupe les logs du journal pendant le test.
+func silenceLogs(t *testing.T) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+}
+
+func TestAppendRollsSegments(t *testing.T) {
+	dir := t.TempDir()
+	// Chaque enregistrement fait 16 octets d'en-tête plus 8 octets de données : trois par segment.
+	j := openTestJournal(t, dir, 3*(recordHeaderSize+8))
+
+	appendRecords(t, j, 7)
+
+	want := []string{segmentName(1), segmentName(4), segmentName(7)}
+	if got := segmentFiles(t, dir); !slices.Equal(got, want) {
+		t.Errorf("segments = %v, want %v", got, want)
+	}
+	if got := readAll(t, j); !slices.Equal(got, []uint64{1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("records = %v, want 1..7 across segments", got)
+	}
+}
+
+func TestOpenRecoversFromTruncatedTail(t *testing.T) {
+	silenceLogs(t)
+	dir := t.TempDir()
+	j := openTestJournal(t, dir, DefaultSegmentSize)
+	appendRecords(t, j, 3)
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Arrêt brutal au milieu de l'écriture du troisième enregistrement.
+	path := segmentPath(dir, 1)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-5); err != nil {
+		t.Fatal(err)
+	}
+
+	j = openTestJournal(t, dir, DefaultSegmentSize)
+	if info, err = os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 2*(recordHeaderSize+8) {
+		t.Fatalf("segment size after recovery = %d, want the two complete records", info.Size())
+	}
+	// Le numéro perdu est réattribué au prochain ajout, écrit à la suite des enregistrements valides.
+	if seqs := appendRecords(t, j, 1); seqs[0] != 3 {
+		t.Errorf("next sequence = %d, want 3", seqs[0])
+	}
+	if got := readAll(t, j); !slices.Equal(got, []uint64{1, 2, 3}) {
+		t.Errorf("records after recovery = %v, want [1 2 3]", got)
+	}
+}
+
+func TestAckAdvancesWatermark(t *testing.T) {
+	j := openTestJournal(t, t.TempDir(), DefaultSegmentSize)
+	appendRecords(t, j, 5)
+
+	// Acquittements dans le désordre, comme avec plusieurs workers.
+	steps := []struct {
+		ack           []uint64
+		wantWatermark uint64
+		wantPending   int
+	}{
+		{[]uint64{3}, 0, 4},
+		{[]uint64{1}, 1, 3},
+		{[]uint64{2, 5}, 3, 1},
+		{[]uint64{2}, 3, 1}, // Un acquittement répété est sans effet
+		{[]uint64{4}, 5, 0},
+	}
+	for _, step := range steps {
+		j.Ack(step.ack...)
+		j.mu.Lock()
+		watermark := j.watermark
+		j.mu.Unlock()
+		if watermark != step.wantWatermark || j.Pending() != step.wantPending {
+			t.Errorf("after Ack(%v): watermark = %d, pending = %d; want %d, %d",
+				step.ack, watermark, j.Pending(), step.wantWatermark, step.wantPending)
+		}
+	}
+}
+
+func TestReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	j := openTestJournal(t, dir, DefaultSegmentSize)
+	appendRecords(t, j, 5)
+	j.Ack(1, 2, 4)
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	j = openTestJournal(t, dir, DefaultSegmentSize)
+	if pending := j.Pending(); pending != 2 {
+		t.Errorf("Pending() after restart = %d, want 2", pending)
+	}
+	// Les nouveaux enregistrements suivent les anciens, sans réutiliser leurs numéros.
+	appendRecords(t, j, 1)
+	if got := readAll(t, j); !slices.Equal(got, []uint64{3, 5, 6}) {
+		t.Errorf("replayed records = %v, want [3 5 6]", got)
+	}
+}
+
+func TestFullyAckedSegmentsAreRemoved(t *testing.T) {
+	dir := t.TempDir()
+	j := openTestJournal(t, dir, 3*(recordHeaderSize+8))
+	appendRecords(t, j, 8) // Segments 1-3, 4-6 et 7-8 (actif)
+
+	// Le premier segment est entièrement acquitté, le deuxième seulement en partie.
+	j.Ack(1, 2, 3, 4, 6)
+	if err := j.sync(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{segmentName(4), segmentName(7)}
+	if got := segmentFiles(t, dir); !slices.Equal(got, want) {
+		t.Errorf("segments = %v, want %v", got, want)
+	}
+
+	// Le segment actif est conservé même entièrement acquitté : les ajouts suivants y sont écrits.
+	j.Ack(5, 7, 8)
+	if err := j.sync(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{segmentName(7)}
+	if got := segmentFiles(t, dir); !slices.Equal(got, want) {
+		t.Errorf("segments = %v, want %v", got, want)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Après un redémarrage, rien n'est rejoué.
+	j = openTestJournal(t, dir, 3*(recordHeaderSize+8))
+	if got := readAll(t, j); len(got) != 0 {
+		t.Errorf("replayed records = %v, want none", got)
+	}
+}
+
+// segmentName retourne le nom du fichier du segment commençant à firstSeq.
+func segmentName(firstSeq uint64) string {
+	return filepath.Base(segmentPath("", firstSeq))
+}
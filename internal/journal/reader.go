@@ -0,0 +1,127 @@
+package journal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reader parcourt les enregistrements non acquittés du journal, dans l'ordre des numéros de séquence.
+// Une fois la fin du journal atteinte, Next attend les ajouts suivants : un même Reader sert
+// donc à rejouer les enregistrements en attente au démarrage puis à suivre les nouveaux.
+// Un Reader n'est pas sûr pour un usage concurrent.
+type Reader struct {
+	j        *Journal
+	nextSeq  uint64
+	segFirst uint64 // Premier numéro de séquence du segment ouvert
+	file     *os.File
+	rd       *bufio.Reader
+}
+
+// NewReader crée un lecteur positionné sur le premier enregistrement non acquitté.
+func (j *Journal) NewReader() *Reader {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &Reader{j: j, nextSeq: j.watermark + 1}
+}
+
+// Next retourne le prochain enregistrement non acquitté, en attendant si nécessaire qu'il soit écrit.
+// Elle retourne ErrClosed lorsque le journal est fermé, et io.EOF lorsqu'il est scellé et entièrement lu.
+func (r *Reader) Next() (uint64, []byte, error) {
+	for {
+		if err := r.waitFor(r.nextSeq); err != nil {
+			r.Close()
+			return 0, nil, err
+		}
+
+		if r.file == nil {
+			if err := r.openSegment(r.nextSeq, false); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		seq, data, err := readRecord(r.rd)
+		if errors.Is(err, io.EOF) {
+			// L'enregistrement attendu a été écrit dans le segment suivant.
+			current := r.segFirst
+			r.Close()
+			if err := r.openSegment(current, true); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read journal record %d: %w", r.nextSeq, err)
+		}
+		if seq < r.nextSeq {
+			continue // Enregistrement antérieur à la position de lecture dans le même segment
+		}
+
+		r.nextSeq = seq + 1
+		r.j.mu.Lock()
+		acked := r.j.isAckedLocked(seq)
+		r.j.mu.Unlock()
+		if acked {
+			continue
+		}
+		return seq, data, nil
+	}
+}
+
+// waitFor bloque jusqu'à ce que l'enregistrement seq soit écrit ou que le journal soit fermé ou scellé.
+func (r *Reader) waitFor(seq uint64) error {
+	r.j.mu.Lock()
+	defer r.j.mu.Unlock()
+	for !r.j.closed && !r.j.sealed && seq > r.j.lastSeq {
+		r.j.cond.Wait()
+	}
+	if r.j.closed {
+		return ErrClosed
+	}
+	if seq > r.j.lastSeq {
+		return io.EOF
+	}
+	return nil
+}
+
+// openSegment ouvre le segment contenant l'enregistrement seq : le dernier segment commençant
+// au plus tard à seq, ou à défaut le premier segment (les précédents ayant été supprimés).
+// Avec next à true, c'est le premier segment commençant après seq qui est ouvert.
+func (r *Reader) openSegment(seq uint64, next bool) error {
+	r.j.mu.Lock()
+	var found *segment
+	for i := range r.j.segments {
+		s := r.j.segments[i]
+		if next {
+			if s.firstSeq > seq {
+				found = &s
+				break
+			}
+			continue
+		}
+		if s.firstSeq <= seq || found == nil {
+			found = &s
+		}
+	}
+	r.j.mu.Unlock()
+
+	if found == nil {
+		return fmt.Errorf("no journal segment for record %d", r.nextSeq)
+	}
+	f, err := os.Open(found.path)
+	if err != nil {
+		return fmt.Errorf("failed to open journal segment %s: %w", found.path, err)
+	}
+	r.file, r.rd, r.segFirst = f, bufio.NewReader(f), found.firstSeq
+	return nil
+}
+
+// Close libère le segment ouvert par le lecteur.
+func (r *Reader) Close() {
+	if r.file != nil {
+		r.file.Close()
+		r.file, r.rd = nil, nil
+	}
+}
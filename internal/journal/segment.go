@@ -0,0 +1,135 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format d'un enregistrement sur disque :
+//
+//	longueur (uint32) | CRC-32C (uint32) | numéro de séquence (uint64) | données
+//
+// Le CRC couvre le numéro de séquence et les données, ce qui permet de détecter
+// un enregistrement tronqué par un arrêt brutal en fin de segment.
+const recordHeaderSize = 16
+
+// segmentExt est l'extension des fichiers de segment, nommés d'après leur premier numéro de séquence.
+const segmentExt = ".seg"
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorruptRecord signale un enregistrement incomplet ou dont le CRC ne correspond pas.
+var errCorruptRecord = errors.New("corrupt journal record")
+
+// segment décrit un fichier de segment du journal.
+type segment struct {
+	firstSeq uint64
+	path     string
+}
+
+// segmentPath retourne le chemin du segment commençant à firstSeq.
+func segmentPath(dir string, firstSeq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", firstSeq, segmentExt))
+}
+
+// listSegments retourne les segments présents dans dir, triés par premier numéro de séquence.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		firstSeq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{firstSeq: firstSeq, path: filepath.Join(dir, name)})
+	}
+	sort.Slice(segments, func(i, k int) bool { return segments[i].firstSeq < segments[k].firstSeq })
+	return segments, nil
+}
+
+// encodeRecord sérialise un enregistrement.
+func encodeRecord(seq uint64, data []byte) []byte {
+	buf := make([]byte, recordHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(data)))
+	binary.LittleEndian.PutUint64(buf[8:16], seq)
+	copy(buf[recordHeaderSize:], data)
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(buf[8:], crcTable))
+	return buf
+}
+
+// readRecord lit l'enregistrement suivant. Elle retourne io.EOF en fin de segment
+// et errCorruptRecord si l'enregistrement est incomplet ou invalide.
+func readRecord(r *bufio.Reader) (uint64, []byte, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.EOF
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, errCorruptRecord
+		}
+		return 0, nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	checksum := binary.LittleEndian.Uint32(header[4:8])
+	seq := binary.LittleEndian.Uint64(header[8:16])
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, errCorruptRecord
+		}
+		return 0, nil, err
+	}
+
+	crc := crc32.Update(crc32.Checksum(header[8:16], crcTable), crcTable, data)
+	if crc != checksum {
+		return 0, nil, errCorruptRecord
+	}
+	return seq, data, nil
+}
+
+// scanSegment parcourt un segment et retourne le dernier numéro de séquence valide
+// ainsi que la taille de la partie valide du fichier. Un enregistrement invalide arrête le parcours :
+// corrupt vaut alors true et la suite du fichier doit être ignorée.
+func scanSegment(path string) (lastSeq uint64, validSize int64, corrupt bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		seq, data, err := readRecord(r)
+		if errors.Is(err, io.EOF) {
+			return lastSeq, validSize, false, nil
+		}
+		if errors.Is(err, errCorruptRecord) {
+			return lastSeq, validSize, true, nil
+		}
+		if err != nil {
+			return 0, 0, false, err
+		}
+		lastSeq = seq
+		validSize += int64(recordHeaderSize + len(data))
+	}
+}
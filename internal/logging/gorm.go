@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold est la durée au-delà de laquelle une requête SQL est signalée comme lente.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger transmet les logs de GORM au logger par défaut (slog) au lieu de les écrire sur la sortie standard.
+// Les erreurs SQL et les requêtes lentes sont journalisées ; les autres requêtes ne le sont qu'au niveau debug.
+// Un enregistrement introuvable n'est pas une erreur : c'est le cas normal d'un code court inconnu.
+type GormLogger struct {
+	level gormlogger.LogLevel
+}
+
+// NewGormLogger crée un GormLogger journalisant les avertissements et les erreurs.
+func NewGormLogger() *GormLogger {
+	return &GormLogger{level: gormlogger.Warn}
+}
+
+// LogMode implémente gormlogger.Interface.
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &GormLogger{level: level}
+}
+
+// Info implémente gormlogger.Interface.
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		gormLog().InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn implémente gormlogger.Interface.
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		gormLog().WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error implémente gormlogger.Interface.
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		gormLog().ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace implémente gormlogger.Interface.
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	logger := gormLog()
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gormlogger.ErrRecordNotFound):
+		sql, rows := fc()
+		logger.ErrorContext(ctx, "SQL query failed", queryAttrs(sql, rows, elapsed, "error", err)...)
+	case elapsed > slowQueryThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.WarnContext(ctx, "Slow SQL query", queryAttrs(sql, rows, elapsed)...)
+	case logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		logger.DebugContext(ctx, "SQL query", queryAttrs(sql, rows, elapsed)...)
+	}
+}
+
+// queryAttrs construit les attributs communs aux logs d'une requête SQL.
+func queryAttrs(sql string, rows int64, elapsed time.Duration, extra ...any) []any {
+	attrs := []any{"sql", sql, "rows", rows, "duration_ms", float64(elapsed.Microseconds()) / 1000}
+	return append(attrs, extra...)
+}
+
+// gormLog retourne le logger des requêtes SQL, dérivé du logger par défaut au moment de l'appel.
+func gormLog() *slog.Logger {
+	return slog.Default().With("component", "gorm")
+}
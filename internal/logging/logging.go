@@ -0,0 +1,51 @@
+// Package logging configure le logger structuré (log/slog) utilisé par le serveur.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats de sortie acceptés.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New crée un logger écrivant dans w au niveau (debug, info, warn, error) et au format (text, json) donnés.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(defaultString(level, "info")))); err != nil {
+		return nil, fmt.Errorf("invalid log level '%s' (expected debug, info, warn or error)", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch defaultString(format, FormatText) {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format '%s' (expected %s or %s)", format, FormatText, FormatJSON)
+	}
+}
+
+// Setup crée le logger et l'installe comme logger par défaut. Les appels restants
+// au package log standard passent alors eux aussi par ce logger, au niveau info.
+func Setup(w io.Writer, level, format string) error {
+	logger, err := New(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
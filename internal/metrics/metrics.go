@@ -0,0 +1,206 @@
+// Package metrics expose les métriques Prometheus du service (redirections, clics, moniteur).
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace préfixe le nom de toutes les métriques du service.
+const namespace = "urlshortener"
+
+// Metrics regroupe les métriques d'un serveur, enregistrées dans le registre qui lui est propre.
+// Chaque serveur crée ses métriques et les injecte dans les composants qui les alimentent,
+// si bien que plusieurs serveurs peuvent coexister dans un même processus (tests, par exemple).
+// Toutes les méthodes acceptent un récepteur nil, qui ne mesure rien.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// redirectDuration mesure la durée de traitement des redirections, par code HTTP de réponse.
+	redirectDuration *prometheus.HistogramVec
+	// linksCreated compte les liens créés via l'API.
+	linksCreated prometheus.Counter
+	// monitorCheckDuration mesure la durée des vérifications d'URL du moniteur, par résultat.
+	monitorCheckDuration *prometheus.HistogramVec
+	// linkUp indique l'état de la destination de chaque lien lors de la dernière vérification (1 = accessible).
+	linkUp *prometheus.GaugeVec
+	// notificationsSent compte les notifications du moniteur, par canal et par résultat (sent ou error).
+	notificationsSent *prometheus.CounterVec
+	// routingSwitches compte les bascules de routage des liens, par routage cible (fallback ou primary).
+	routingSwitches *prometheus.CounterVec
+	// linksQuarantined compte les liens mis en quarantaine par l'analyse des listes de blocage.
+	linksQuarantined prometheus.Counter
+}
+
+// New crée les métriques du service et les enregistre dans registry,
+// avec les collecteurs du runtime Go et du processus.
+func New(registry *prometheus.Registry) *Metrics {
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	factory := promauto.With(registry)
+
+	return &Metrics{
+		registry: registry,
+		redirectDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "redirect_duration_seconds",
+			Help:      "Durée de traitement des redirections, par code HTTP.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"status"}),
+		linksCreated: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "links_created_total",
+			Help:      "Nombre de liens courts créés via l'API.",
+		}),
+		monitorCheckDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "monitor_check_duration_seconds",
+			Help:      "Durée des vérifications d'accessibilité des URLs longues.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 10), // 10 ms à ~5 s (délai maximal d'une vérification)
+		}, []string{"result"}),
+		linkUp: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "link_up",
+			Help:      "État de l'URL longue lors de la dernière vérification du moniteur (1 = accessible, 0 = inaccessible).",
+		}, []string{"short_code"}),
+		notificationsSent: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_total",
+			Help:      "Notifications de changement d'état des liens, par canal et par résultat.",
+		}, []string{"notifier", "result"}),
+		routingSwitches: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "link_routing_switches_total",
+			Help:      "Bascules des redirections vers l'URL de repli (fallback) ou vers l'URL longue (primary).",
+		}, []string{"routing"}),
+		linksQuarantined: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "links_quarantined_total",
+			Help:      "Liens mis en quarantaine parce qu'une destination figure dans une liste de blocage.",
+		}),
+	}
+}
+
+// Handler retourne le handler Gin de la route /metrics (format texte Prometheus) pour le registre de m.
+func (m *Metrics) Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry}))
+}
+
+// ObserveRedirect est un middleware Gin qui mesure la durée de la redirection et l'enregistre
+// avec le code HTTP effectivement renvoyé.
+func (m *Metrics) ObserveRedirect() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		if m != nil {
+			m.redirectDuration.WithLabelValues(strconv.Itoa(c.Writer.Status())).Observe(time.Since(start).Seconds())
+		}
+	}
+}
+
+// LinkCreated compte un lien créé via l'API.
+func (m *Metrics) LinkCreated() {
+	if m == nil {
+		return
+	}
+	m.linksCreated.Inc()
+}
+
+// ObserveCheck enregistre la durée et le résultat d'une vérification d'URL du moniteur.
+func (m *Metrics) ObserveCheck(shortCode string, up bool, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	result, value := "down", 0.0
+	if up {
+		result, value = "up", 1.0
+	}
+	m.monitorCheckDuration.WithLabelValues(result).Observe(duration.Seconds())
+	m.linkUp.WithLabelValues(shortCode).Set(value)
+}
+
+// ForgetLink retire l'état link_up d'un lien qui n'est plus surveillé (supprimé entre deux passes).
+func (m *Metrics) ForgetLink(shortCode string) {
+	if m == nil {
+		return
+	}
+	m.linkUp.DeleteLabelValues(shortCode)
+}
+
+// NotificationSent compte une notification envoyée par notifier, ou son échec si err n'est pas nil.
+func (m *Metrics) NotificationSent(notifier string, err error) {
+	if m == nil {
+		return
+	}
+	result := "sent"
+	if err != nil {
+		result = "error"
+	}
+	m.notificationsSent.WithLabelValues(notifier, result).Inc()
+}
+
+// RoutingSwitched compte une bascule de routage d'un lien vers routing (fallback ou primary).
+func (m *Metrics) RoutingSwitched(routing string) {
+	if m == nil {
+		return
+	}
+	m.routingSwitches.WithLabelValues(routing).Inc()
+}
+
+// LinkQuarantined compte un lien mis en quarantaine.
+func (m *Metrics) LinkQuarantined() {
+	if m == nil {
+		return
+	}
+	m.linksQuarantined.Inc()
+}
+
+// RegisterClickPipeline expose l'état du pipeline de clics : profondeur et capacité de la file,
+// événements abandonnés et erreurs d'écriture des workers. Les valeurs sont lues à chaque collecte.
+func (m *Metrics) RegisterClickPipeline(pipeline *workers.ClickPipeline) error {
+	pipelineCollectors := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_queue_depth",
+			Help:      "Nombre d'événements de clic en attente dans le channel des workers.",
+		}, func() float64 { return float64(pipeline.Stats().Queued) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_queue_capacity",
+			Help:      "Capacité du channel des événements de clic.",
+		}, func() float64 { return float64(pipeline.Stats().Capacity) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_journal_pending",
+			Help:      "Nombre d'événements de clic journalisés et pas encore persistés.",
+		}, func() float64 { return float64(pipeline.Stats().JournalPending) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_events_dropped_total",
+			Help:      "Nombre d'événements de clic abandonnés (channel plein ou pipeline arrêté).",
+		}, func() float64 { return float64(pipeline.Stats().Dropped) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "clicks_recorded_total",
+			Help:      "Nombre de clics persistés par les workers.",
+		}, func() float64 { return float64(pipeline.Stats().Recorded) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_persist_errors_total",
+			Help:      "Nombre de clics dont l'écriture en base par les workers a échoué.",
+		}, func() float64 { return float64(pipeline.Stats().Failed) }),
+	}
+
+	for _, collector := range pipelineCollectors {
+		if err := m.registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
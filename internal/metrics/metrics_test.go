@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestNewWithSeparateRegistries vérifie que deux serveurs d'un même processus ont chacun leurs métriques.
+func TestNewWithSeparateRegistries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	first := New(prometheus.NewRegistry())
+	second := New(prometheus.NewRegistry())
+
+	first.LinkCreated()
+	first.ObserveCheck("abc123", true, 20*time.Millisecond)
+
+	if body := scrape(t, first); !strings.Contains(body, "urlshortener_links_created_total 1") ||
+		!strings.Contains(body, `urlshortener_link_up{short_code="abc123"} 1`) {
+		t.Errorf("first registry is missing its metrics:\n%s", body)
+	}
+	if body := scrape(t, second); !strings.Contains(body, "urlshortener_links_created_total 0") ||
+		strings.Contains(body, "abc123") {
+		t.Errorf("second registry shares metrics with the first:\n%s", body)
+	}
+}
+
+// TestNilMetrics vérifie qu'un composant sans métriques peut appeler leurs méthodes.
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.LinkCreated()
+	m.ObserveCheck("abc123", false, time.Second)
+	m.NotificationSent("webhook", nil)
+	m.RoutingSwitched("fallback")
+	m.LinkQuarantined()
+}
+
+// scrape retourne le contenu servi par le handler /metrics de m.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	router := gin.New()
+	router.GET("/metrics", m.Handler())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics returned %d", rec.Code)
+	}
+	return rec.Body.String()
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scopes (permissions) d'une clé d'API.
+const (
+	ScopeLinksWrite = "links:write" // Créer, modifier, supprimer et restaurer des liens
+	ScopeLinksRead  = "links:read"  // Lister les liens
+	ScopeStatsRead  = "stats:read"  // Consulter les statistiques et l'état de santé des liens
+	ScopeAdmin      = "admin"       // Administration (quarantaine) ; implique tous les autres scopes
+)
+
+// AllScopes liste les scopes reconnus.
+var AllScopes = []string{ScopeLinksWrite, ScopeLinksRead, ScopeStatsRead, ScopeAdmin}
+
+// APIKey est une clé d'accès à l'API REST. Seule l'empreinte SHA-256 de la clé est conservée :
+// la clé elle-même n'est affichée qu'une fois, à sa création.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey"`
+	Name       string     `gorm:"size:100;not null"`            // Libellé choisi à la création (service, personne...)
+	Prefix     string     `gorm:"size:16;not null"`             // Début de la clé, pour la reconnaître sans la divulguer
+	Hash       string     `gorm:"size:64;not null;uniqueIndex"` // Empreinte SHA-256 hexadécimale de la clé
+	Scopes     string     `gorm:"not null"`                     // Scopes séparés par des virgules
+	UserID     *uint      `gorm:"index"`                        // Utilisateur au nom duquel la clé agit (nil = liens sans propriétaire uniquement)
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	ExpiresAt  *time.Time // Date d'expiration optionnelle (nil = n'expire jamais)
+	LastUsedAt *time.Time // Dernière utilisation (mise à jour au plus une fois par minute)
+	RevokedAt  *time.Time // Date de révocation (nil = active)
+}
+
+// ScopeList retourne les scopes de la clé.
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope indique si la clé accorde un scope. Le scope admin accorde tous les autres.
+func (k *APIKey) HasScope(scope string) bool {
+	scopes := k.ScopeList()
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// IsExpired indique si la date d'expiration de la clé est dépassée à l'instant donné.
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
@@ -11,12 +11,38 @@ type Click struct {
 	Timestamp time.Time // Horodatage précis du clic
 	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
 	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+
+	Referrer       string `gorm:"size:512"`       // En-tête Referer brut (tronqué)
+	ReferrerDomain string `gorm:"index;size:255"` // Domaine référent normalisé (vide = accès direct)
+
+	Browser    string `gorm:"size:50"`                      // Famille de navigateur déduite du User-Agent
+	OS         string `gorm:"size:50"`                      // Famille de système d'exploitation déduite du User-Agent
+	DeviceType string `gorm:"size:20"`                      // desktop, mobile, tablet ou unknown
+	IsBot      bool   `gorm:"index;not null;default:false"` // Clic émis par un robot ou un générateur d'aperçu de lien
+
+	Country string `gorm:"index;size:2"` // Code pays ISO déduit de l'adresse IP (vide si GeoIP non configuré)
+	Region  string `gorm:"size:100"`     // Région déduite de l'adresse IP
+	City    string `gorm:"size:100"`     // Ville déduite de l'adresse IP
 }
 
-// ClickEvent représente un événement de clic brut, destiné à être passé via un channel
+// ClickEvent représente un événement de clic brut, transmis par la redirection au pipeline de clics
+// puis converti en Click par les workers.
 type ClickEvent struct {
 	LinkID    uint
+	ShortCode string
+	LongURL   string
 	Timestamp time.Time
+	IP        string
 	UserAgent string
-	IPAddress string
+	Referrer  string
+	RequestID string // Identifiant de la requête de redirection, pour relier les logs des workers à celle-ci
+	IsBot     bool   // Robot détecté par la redirection sur le User-Agent complet, même si UserAgent est vidé (DNT/GPC)
+
+	// Localisation résolue sur l'adresse complète avant l'application de analytics.ip_mode à IP.
+	Country     string
+	Region      string
+	City        string
+	IPProtected bool // IP a déjà reçu le mode de conservation (voir analytics.Enricher.ProtectEvent)
+
+	JournalSeq uint64 `json:"-"` // Numéro de l'événement dans le journal (0 si non journalisé), à acquitter après persistance
 }
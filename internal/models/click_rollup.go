@@ -0,0 +1,12 @@
+package models
+
+// ClickRollup agrège les clics d'un lien sur une journée UTC.
+// Les clics plus anciens que la durée de rétention sont regroupés dans cette table
+// puis supprimés de 'clicks', afin que les totaux restent exacts sans conserver chaque clic.
+type ClickRollup struct {
+	ID        uint   `gorm:"primaryKey"`
+	LinkID    uint   `gorm:"not null;uniqueIndex:idx_click_rollups_link_day"`         // Lien concerné
+	Day       string `gorm:"size:10;not null;uniqueIndex:idx_click_rollups_link_day"` // Jour UTC au format AAAA-MM-JJ
+	Clicks    int    `gorm:"not null;default:0"`                                      // Clics humains du jour
+	BotClicks int    `gorm:"not null;default:0"`                                      // Clics de robots du jour
+}
@@ -1,10 +1,57 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Link struct {
 	ID        uint      `gorm:"primaryKey"`           // Clé primaire
-	Shortcode string    `gorm:"unique;index;size:10"` // Code court unique, indexé pour des recherches rapides
+	Shortcode string    `gorm:"unique;index;size:32"` // Code court unique (généré ou alias personnalisé), indexé pour des recherches rapides
 	LongURL   string    `gorm:"not null"`             // URL complète du lien
 	CreatedAt time.Time `gorm:"autoCreateTime"`       // Horodatage de la création du lien
+
+	ExpiresAt  *time.Time `gorm:"index"`              // Date d'expiration optionnelle (nil = le lien n'expire jamais)
+	MaxClicks  int        `gorm:"not null;default:0"` // Budget maximal de redirections (0 = illimité)
+	UsedClicks int        `gorm:"not null;default:0"` // Redirections déjà consommées sur le budget, incrémenté de façon atomique
+
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`         // Horodatage de la dernière modification du lien
+	Disabled  bool           `gorm:"not null;default:false"` // Un lien désactivé ne redirige plus mais reste consultable
+	DeletedAt gorm.DeletedAt `gorm:"index"`                  // Suppression logique (soft-delete) : l'historique des clics est conservé
+
+	FallbackURL  string     // URL de repli optionnelle, servie tant que l'URL longue est en panne
+	FailedOver   bool       `gorm:"not null;default:false"` // Le moniteur a basculé les redirections vers FallbackURL
+	FailedOverAt *time.Time // Début de la bascule en cours
+
+	Quarantined        bool       `gorm:"not null;default:false;index"` // Une destination figure dans une liste de blocage : avertissement au lieu de la redirection
+	QuarantinedAt      *time.Time // Date de la mise en quarantaine
+	QuarantineReason   string     // Entrée de la liste de blocage correspondante
+	QuarantineOverride bool       `gorm:"not null;default:false"` // Libéré par un administrateur : les analyses ne le remettent pas en quarantaine
+
+	ClickCount int `gorm:"not null;default:0;index"` // Clics humains enregistrés (hors robots, agrégats de rétention compris), incrémenté avec chaque lot de clics
+
+	OwnerID *uint `gorm:"index"` // Utilisateur propriétaire (nil = lien antérieur aux utilisateurs ou créé sans propriétaire)
+}
+
+// IsExpired indique si la date d'expiration du lien est dépassée à l'instant donné.
+func (l *Link) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// Routing retourne le routage en vigueur : RoutingFallback si le moniteur a basculé le lien
+// et qu'une URL de repli est (toujours) configurée, RoutingPrimary sinon.
+func (l *Link) Routing() string {
+	if l.FailedOver && l.FallbackURL != "" {
+		return RoutingFallback
+	}
+	return RoutingPrimary
+}
+
+// Destination retourne l'URL vers laquelle rediriger les visiteurs selon le routage en vigueur.
+func (l *Link) Destination() string {
+	if l.Routing() == RoutingFallback {
+		return l.FallbackURL
+	}
+	return l.LongURL
 }
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Classes d'erreur d'une vérification d'URL (LinkCheck.ErrorClass). Une vérification réussie n'a pas de classe.
+const (
+	CheckErrorTimeout    = "timeout"     // Délai de réponse dépassé
+	CheckErrorDNS        = "dns"         // Nom de domaine introuvable
+	CheckErrorConnection = "connection"  // Connexion refusée ou interrompue
+	CheckErrorTLS        = "tls"         // Certificat invalide ou négociation TLS échouée
+	CheckErrorHTTPStatus = "http_status" // Réponse reçue avec un code HTTP d'erreur (4xx/5xx)
+	CheckErrorInvalidURL = "invalid_url" // URL impossible à requêter
+	CheckErrorRedirects  = "redirects"   // Boucle de redirections ou chaîne trop longue
+	CheckErrorSoft404    = "soft_404"    // Réponse 2xx dont le contenu indique une page introuvable ou parquée
+	CheckErrorForbidden  = "forbidden"   // L'URL (ou une redirection) vise une adresse non publique, refusée par le dialer
+	CheckErrorOther      = "other"       // Toute autre erreur réseau
+)
+
+// États de la destination d'un lien d'après une vérification.
+const (
+	LinkStateUp      = "up"      // Accessible
+	LinkStateDown    = "down"    // Inaccessible
+	LinkStateChanged = "changed" // Accessible, mais après redirection vers un autre hôte que celui d'origine
+)
+
+// RedirectHop est une étape de la chaîne de redirections suivie lors d'une vérification.
+type RedirectHop struct {
+	URL    string `json:"url"`    // URL ayant répondu par une redirection
+	Status int    `json:"status"` // Code HTTP de la redirection (301, 302, 307...)
+}
+
+// LinkCheck est le résultat d'une vérification de l'URL longue d'un lien par le moniteur.
+// L'historique permet de connaître l'état des destinations après un redémarrage et d'en calculer la disponibilité.
+type LinkCheck struct {
+	ID         uint      `gorm:"primaryKey"`
+	LinkID     uint      `gorm:"not null;index:idx_link_checks_link_time"`       // Lien vérifié
+	CheckedAt  time.Time `gorm:"not null;index:idx_link_checks_link_time;index"` // Instant de la vérification (UTC)
+	Up         bool      `gorm:"not null"`                                       // URL accessible (réponse finale 2xx/3xx, hors soft-404)
+	StatusCode int       // Code HTTP reçu (0 si aucune réponse)
+	LatencyMs  int64     // Durée de la vérification en millisecondes
+	ErrorClass string    `gorm:"size:20"` // Classe d'erreur (vide si accessible)
+
+	FinalURL      string        `gorm:"size:2048"`              // URL ayant donné la réponse finale (vide sans redirection)
+	RedirectChain []RedirectHop `gorm:"serializer:json"`        // Redirections suivies, dans l'ordre
+	HostChanged   bool          `gorm:"not null;default:false"` // L'hôte final diffère de l'hôte d'origine
+}
+
+// State retourne l'état de la destination d'après cette vérification.
+func (c LinkCheck) State() string {
+	switch {
+	case !c.Up:
+		return LinkStateDown
+	case c.HostChanged:
+		return LinkStateChanged
+	default:
+		return LinkStateUp
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Routage des redirections d'un lien.
+const (
+	RoutingPrimary  = "primary"  // Les visiteurs sont redirigés vers l'URL longue
+	RoutingFallback = "fallback" // L'URL longue est en panne : les visiteurs sont redirigés vers l'URL de repli
+)
+
+// LinkRoutingEvent enregistre une bascule du routage d'un lien, décidée par le moniteur d'URLs.
+type LinkRoutingEvent struct {
+	ID                  uint      `gorm:"primaryKey"`
+	LinkID              uint      `gorm:"not null;index:idx_link_routing_events_link_time"`
+	OccurredAt          time.Time `gorm:"not null;index:idx_link_routing_events_link_time"`
+	Routing             string    `gorm:"size:16;not null"` // Routage en vigueur après la bascule (RoutingPrimary ou RoutingFallback)
+	ConsecutiveFailures int       // Échecs consécutifs ayant déclenché la bascule vers le repli
+	StatusCode          int       // Code HTTP de la vérification ayant déclenché la bascule (0 si aucune réponse)
+	ErrorClass          string    `gorm:"size:20"` // Classe d'erreur de cette vérification
+}
@@ -0,0 +1,6 @@
+package models
+
+// All retourne les modèles gérés par les migrations automatiques de GORM.
+func All() []interface{} {
+	return []interface{}{&Link{}, &Click{}, &ClickRollup{}, &LinkCheck{}, &LinkRoutingEvent{}, &APIKey{}, &User{}}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// User est un utilisateur (personne ou équipe) propriétaire de liens et de clés d'API.
+// Via l'API, un utilisateur ne voit que ses propres liens ; les clés portant le scope admin les voient tous.
+type User struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"size:100;not null;uniqueIndex"` // Identifiant lisible, utilisé par la CLI (--owner, --user)
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/notify"
+)
+
+// DefaultFailureThreshold est le nombre d'échecs consécutifs au-delà duquel un lien est signalé inaccessible.
+const DefaultFailureThreshold = 3
+
+// linkStatus est ce que le moniteur sait d'un lien entre deux vérifications.
+type linkStatus struct {
+	state     string    // Dernier état (models.LinkState*), vide si jamais vérifié
+	failures  int       // Vérifications consécutives en échec
+	downSince time.Time // Premier échec de la série en cours (zéro si inconnu, par exemple avant un redémarrage)
+	alerted   bool      // Une alerte a été émise pour la série d'échecs en cours
+}
+
+// observe met à jour le nombre d'échecs consécutifs avec le résultat d'une vérification
+// et retourne l'événement à notifier (vide si aucun) : link_down lorsque la série atteint threshold,
+// link_recovered au premier succès suivant une alerte. Une série d'échecs plus courte ne déclenche rien.
+func (s *linkStatus) observe(check models.LinkCheck, threshold int) (eventType string, downSince time.Time, failures int) {
+	if !check.Up {
+		if s.failures == 0 {
+			s.downSince = check.CheckedAt
+		}
+		s.failures++
+		if !s.alerted && s.failures >= threshold {
+			s.alerted = true
+			return notify.EventLinkDown, s.downSince, s.failures
+		}
+		return "", s.downSince, s.failures
+	}
+
+	eventType, downSince, failures = "", s.downSince, s.failures
+	if s.alerted {
+		eventType = notify.EventLinkRecovered
+	}
+	s.failures, s.alerted, s.downSince = 0, false, time.Time{}
+	return eventType, downSince, failures
+}
+
+// notify transmet un événement de changement d'état d'un lien au notifier configuré.
+func (m *UrlMonitor) notify(ctx context.Context, eventType string, link models.Link, check models.LinkCheck, downSince time.Time, failures int) {
+	if m.opts.Notifier == nil {
+		return
+	}
+
+	event := notify.Event{
+		Type:       eventType,
+		LinkID:     link.ID,
+		ShortCode:  link.Shortcode,
+		LongURL:    link.LongURL,
+		OccurredAt: check.CheckedAt,
+		StatusCode: check.StatusCode,
+		ErrorClass: check.ErrorClass,
+		FinalURL:   check.FinalURL,
+	}
+	if eventType == notify.EventLinkDown {
+		event.ConsecutiveFailures = failures
+	}
+	if !downSince.IsZero() {
+		event.DownSince = &downSince
+	}
+	if err := m.opts.Notifier.Notify(ctx, event); err != nil {
+		logger().Error("Failed to queue notification", "event", eventType, "short_code", link.Shortcode, "error", err)
+	}
+}
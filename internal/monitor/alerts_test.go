@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/notify"
+)
+
+func TestLinkStatusObserve(t *testing.T) {
+	const threshold = 3
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	// Deux séries d'échecs : une trop courte pour alerter, puis une panne confirmée suivie d'un rétablissement.
+	steps := []struct {
+		up            bool
+		wantEvent     string
+		wantFailures  int
+		wantDownSince int // Indice de la vérification ayant ouvert la série (-1 = aucune)
+	}{
+		{false, "", 1, 0},
+		{false, "", 2, 0},
+		{true, "", 2, 0}, // Série inférieure au seuil : rétablissement silencieux
+		{false, "", 1, 3},
+		{false, "", 2, 3},
+		{false, notify.EventLinkDown, 3, 3}, // Seuil atteint : une seule alerte...
+		{false, "", 4, 3},                   // ...même si la panne se prolonge
+		{false, "", 5, 3},
+		{true, notify.EventLinkRecovered, 5, 3},
+		{true, "", 0, -1},
+		{false, "", 1, 10},
+	}
+
+	status := &linkStatus{}
+	for i, step := range steps {
+		check := models.LinkCheck{Up: step.up, CheckedAt: start.Add(time.Duration(i) * time.Minute)}
+		event, downSince, failures := status.observe(check, threshold)
+		if event != step.wantEvent || failures != step.wantFailures {
+			t.Errorf("step %d: observe() = %q, %d failures; want %q, %d", i, event, failures, step.wantEvent, step.wantFailures)
+		}
+		wantDownSince := time.Time{}
+		if step.wantDownSince >= 0 {
+			wantDownSince = start.Add(time.Duration(step.wantDownSince) * time.Minute)
+		}
+		if !downSince.Equal(wantDownSince) {
+			t.Errorf("step %d: downSince = %s, want %s", i, downSince, wantDownSince)
+		}
+	}
+}
+
+func TestLinkStatusObserveAfterRestart(t *testing.T) {
+	// Au redémarrage, loadStatuses marque comme déjà signalée une série ayant atteint le seuil.
+	status := &linkStatus{state: models.LinkStateDown, failures: 4, alerted: true}
+
+	if event, _, failures := status.observe(models.LinkCheck{Up: false}, 3); event != "" || failures != 5 {
+		t.Errorf("observe(down) = %q, %d failures; want no event, 5 failures", event, failures)
+	}
+	if event, downSince, _ := status.observe(models.LinkCheck{Up: true}, 3); event != notify.EventLinkRecovered || !downSince.IsZero() {
+		t.Errorf("observe(up) = %q, downSince %s; want %q with unknown downSince", event, downSince, notify.EventLinkRecovered)
+	}
+}
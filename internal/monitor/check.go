@@ -0,0 +1,200 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
+)
+
+// DefaultMaxRedirects est le nombre maximal de redirections suivies par défaut lors d'une vérification.
+const DefaultMaxRedirects = 10
+
+// soft404SniffBytes est la quantité de contenu lue pour détecter une page introuvable servie avec un code 2xx.
+const soft404SniffBytes = 16 << 10
+
+// Erreurs de redirection, classées models.CheckErrorRedirects.
+var (
+	errRedirectLoop     = errors.New("redirect loop")
+	errTooManyRedirects = errors.New("too many redirects")
+)
+
+// compileSoft404Patterns compile les motifs (expressions régulières, insensibles à la casse)
+// signalant qu'une page 2xx est en réalité introuvable ou parquée.
+func compileSoft404Patterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid soft-404 pattern '%s': %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// checkUrl vérifie l'accessibilité d'une URL et retourne le résultat à enregistrer
+// (code HTTP, latence, classe d'erreur, chaîne de redirections).
+//
+// La vérification utilise une requête HEAD, remplacée par un GET partiel (en-tête Range) si le serveur
+// répond 405 ou 501, ou d'emblée si des motifs de soft-404 sont configurés (il faut alors lire le contenu).
+// Les redirections sont suivies une à une, au plus MaxRedirects fois et sans jamais repasser par la même URL.
+// La vérification complète, redirections comprises, est limitée à Timeout.
+func (m *UrlMonitor) checkUrl(ctx context.Context, urlStr string) (check models.LinkCheck) {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	check = models.LinkCheck{CheckedAt: start.UTC()}
+	defer func() { check.LatencyMs = time.Since(start).Milliseconds() }()
+
+	current, err := url.Parse(urlStr)
+	if err != nil || (current.Scheme != "http" && current.Scheme != "https") {
+		logger().Warn("Invalid URL", "url", urlStr, "error", err)
+		check.ErrorClass = models.CheckErrorInvalidURL
+		return check
+	}
+	originalHost := normalizeHost(current.Hostname())
+
+	method := http.MethodHead
+	if len(m.soft404) > 0 {
+		method = http.MethodGet
+	}
+	visited := map[string]bool{current.String(): true}
+
+	for {
+		resp, err := m.do(ctx, method, current)
+		if err == nil && method == http.MethodHead &&
+			(resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+			// HEAD refusé : même URL en GET partiel, puis GET pour le reste de la chaîne.
+			resp.Body.Close()
+			method = http.MethodGet
+			resp, err = m.do(ctx, method, current)
+		}
+		if err != nil {
+			logger().Debug("URL not reachable", "url", urlStr, "error", err)
+			check.ErrorClass = classifyCheckError(err)
+			return check
+		}
+
+		location := resp.Header.Get("Location")
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 && location != "" {
+			resp.Body.Close()
+			check.RedirectChain = append(check.RedirectChain, models.RedirectHop{URL: current.String(), Status: resp.StatusCode})
+
+			next, err := current.Parse(location)
+			switch {
+			case err != nil || (next.Scheme != "http" && next.Scheme != "https"):
+				err = fmt.Errorf("invalid redirect location '%s'", location)
+			case visited[next.String()]:
+				err = errRedirectLoop
+			case len(check.RedirectChain) > m.opts.MaxRedirects:
+				err = errTooManyRedirects
+			}
+			if err != nil {
+				logger().Debug("URL not reachable", "url", urlStr, "error", err)
+				check.StatusCode = resp.StatusCode
+				check.ErrorClass = models.CheckErrorRedirects
+				return check
+			}
+			visited[next.String()] = true
+			current = next
+			continue
+		}
+
+		check.StatusCode = resp.StatusCode
+		check.Up = resp.StatusCode >= 200 && resp.StatusCode < 400
+		if len(check.RedirectChain) > 0 {
+			check.FinalURL = current.String()
+			check.HostChanged = normalizeHost(current.Hostname()) != originalHost
+		}
+		if !check.Up {
+			check.ErrorClass = models.CheckErrorHTTPStatus
+		} else if method == http.MethodGet && m.isSoft404(resp.Body) {
+			check.Up = false
+			check.ErrorClass = models.CheckErrorSoft404
+		}
+		resp.Body.Close()
+		return check
+	}
+}
+
+// do envoie une requête de vérification sans suivre les redirections (elles le sont par checkUrl).
+// Un GET ne demande que le début du contenu, suffisant pour détecter un soft-404.
+func (m *UrlMonitor) do(ctx context.Context, method string, target *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", soft404SniffBytes-1))
+	}
+	return m.client.Do(req)
+}
+
+// isSoft404 indique si le début du contenu d'une réponse correspond à l'un des motifs de soft-404.
+func (m *UrlMonitor) isSoft404(body io.Reader) bool {
+	if len(m.soft404) == 0 {
+		return false
+	}
+	content, err := io.ReadAll(io.LimitReader(body, soft404SniffBytes))
+	if err != nil && len(content) == 0 {
+		return false
+	}
+	for _, re := range m.soft404 {
+		if re.Match(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHost ramène un nom d'hôte à une forme comparable (minuscules, sans préfixe "www.").
+func normalizeHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// classifyCheckError range une erreur de requête dans l'une des classes d'erreur de models.LinkCheck.
+func classifyCheckError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+
+	switch {
+	case errors.Is(err, urlpolicy.ErrForbiddenAddress):
+		return models.CheckErrorForbidden
+	case errors.As(err, &dnsErr):
+		return models.CheckErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.CheckErrorTimeout
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidCert), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return models.CheckErrorTLS
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH):
+		return models.CheckErrorConnection
+	default:
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			return models.CheckErrorConnection
+		}
+		return models.CheckErrorOther
+	}
+}
@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// updateRouting bascule les redirections d'un lien vers son URL de repli lorsque l'URL longue a échoué
+// FailoverThreshold vérifications consécutives, puis les rétablit vers l'URL longue dès qu'elle répond de nouveau.
+// failures est le nombre d'échecs consécutifs, vérification courante comprise.
+func (m *UrlMonitor) updateRouting(link models.Link, check models.LinkCheck, failures int) {
+	var routing string
+	switch {
+	case !check.Up && !link.FailedOver && link.FallbackURL != "" && failures >= m.opts.FailoverThreshold:
+		routing = models.RoutingFallback
+	case check.Up && link.FailedOver:
+		routing = models.RoutingPrimary
+	default:
+		return
+	}
+
+	event := &models.LinkRoutingEvent{
+		OccurredAt: check.CheckedAt,
+		Routing:    routing,
+		StatusCode: check.StatusCode,
+		ErrorClass: check.ErrorClass,
+	}
+	if routing == models.RoutingFallback {
+		event.ConsecutiveFailures = failures
+	}
+
+	switched, err := m.linkRepo.SetRouting(link.ID, event)
+	if err != nil {
+		logger().Error("Failed to switch link routing", "short_code", link.Shortcode, "routing", routing, "error", err)
+		return
+	}
+	if !switched {
+		return
+	}
+
+	m.opts.Metrics.RoutingSwitched(routing)
+	if routing == models.RoutingFallback {
+		logger().Warn("Link failed over to fallback URL",
+			"short_code", link.Shortcode, "long_url", link.LongURL, "fallback_url", link.FallbackURL, "consecutive_failures", failures)
+	} else {
+		logger().Info("Link switched back to primary URL", "short_code", link.Shortcode, "long_url", link.LongURL)
+	}
+}
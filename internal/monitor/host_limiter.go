@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// hostLimiter applique la politesse envers chaque hôte vérifié : au plus concurrency requêtes
+// simultanées, et un délai minimal spacing entre les débuts de deux requêtes vers le même hôte.
+type hostLimiter struct {
+	concurrency int
+	spacing     time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+// hostSlot est l'état du limiteur pour un hôte.
+type hostSlot struct {
+	sem  chan struct{} // Requêtes en cours vers l'hôte
+	next time.Time     // Instant à partir duquel la prochaine requête peut commencer
+}
+
+// newHostLimiter crée un limiteur par hôte.
+func newHostLimiter(concurrency int, spacing time.Duration) *hostLimiter {
+	return &hostLimiter{
+		concurrency: concurrency,
+		spacing:     spacing,
+		hosts:       make(map[string]*hostSlot),
+	}
+}
+
+// acquire attend qu'une requête vers host soit autorisée, puis retourne la fonction qui libère sa place.
+// Une erreur est retournée si ctx est annulé pendant l'attente.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (release func(), err error) {
+	l.mu.Lock()
+	slot, ok := l.hosts[host]
+	if !ok {
+		slot = &hostSlot{sem: make(chan struct{}, l.concurrency)}
+		l.hosts[host] = slot
+	}
+	l.mu.Unlock()
+
+	select {
+	case slot.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release = func() { <-slot.sem }
+
+	// Réserve le prochain créneau de l'hôte : les requêtes en attente sont espacées d'au moins spacing.
+	l.mu.Lock()
+	start := time.Now()
+	if slot.next.After(start) {
+		start = slot.next
+	}
+	slot.next = start.Add(l.spacing)
+	l.mu.Unlock()
+
+	if err := sleepUntil(ctx, start); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// sleepUntil attend l'instant t ou l'annulation de ctx.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,93 +1,360 @@
 package monitor
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/notify"
 	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 )
 
+// Valeurs par défaut du moniteur si la configuration ne les précise pas.
+const (
+	DefaultWorkers            = 20
+	DefaultPerHostConcurrency = 2
+	DefaultPerHostInterval    = time.Second
+	DefaultCheckTimeout       = 5 * time.Second
+)
+
+// jitterSpread est la part de l'intervalle sur laquelle les vérifications d'une passe sont réparties,
+// pour laisser aux dernières le temps de se terminer avant la passe suivante.
+const jitterSpread = 0.9
+
+// Options configure un UrlMonitor.
+type Options struct {
+	Interval             time.Duration    // Intervalle entre deux passes de vérification
+	History              time.Duration    // Durée de conservation de l'historique (0 = illimitée)
+	Workers              int              // Nombre maximal de vérifications simultanées
+	PerHostConcurrency   int              // Nombre maximal de vérifications simultanées vers un même hôte
+	PerHostInterval      time.Duration    // Délai minimal entre deux vérifications vers un même hôte
+	Timeout              time.Duration    // Délai maximal d'une vérification
+	Jitter               bool             // Répartit aléatoirement les vérifications sur l'intervalle au lieu de les lancer d'un coup
+	MaxRedirects         int              // Nombre maximal de redirections suivies par vérification
+	Soft404Patterns      []string         // Expressions régulières signalant une page 2xx introuvable ou parquée (vide = désactivé)
+	FailureThreshold     int              // Échecs consécutifs avant de signaler un lien inaccessible
+	FailoverThreshold    int              // Échecs consécutifs avant de rediriger vers l'URL de repli d'un lien
+	AllowPrivateNetworks bool             // Autorise les connexions vers localhost et les réseaux privés (refusées par défaut)
+	Notifier             notify.Notifier  // Destinataire des alertes link_down / link_recovered (nil = journalisation seule)
+	Metrics              *metrics.Metrics // Métriques des vérifications et des bascules (nil = non mesurées)
+}
+
+// withDefaults remplace les valeurs nulles ou négatives par les valeurs par défaut.
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = DefaultWorkers
+	}
+	if o.PerHostConcurrency <= 0 {
+		o.PerHostConcurrency = DefaultPerHostConcurrency
+	}
+	if o.PerHostInterval <= 0 {
+		o.PerHostInterval = DefaultPerHostInterval
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultCheckTimeout
+	}
+	if o.MaxRedirects <= 0 {
+		o.MaxRedirects = DefaultMaxRedirects
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = DefaultFailureThreshold
+	}
+	if o.FailoverThreshold <= 0 {
+		o.FailoverThreshold = DefaultFailureThreshold
+	}
+	return o
+}
+
 // UrlMonitor gère la surveillance périodique des URLs longues.
+// Chaque passe répartit les vérifications sur l'intervalle et les exécute en parallèle (pool borné),
+// en limitant la charge imposée à chaque hôte. Chaque vérification est enregistrée dans l'historique
+// (table 'link_checks'), dont la dernière valeur sert d'état connu au redémarrage.
 type UrlMonitor struct {
-	linkRepo    repository.LinkRepository
-	interval    time.Duration
-	knownStates map[uint]bool
-	mu          sync.Mutex
+	linkRepo  repository.LinkRepository
+	checkRepo repository.LinkCheckRepository
+	opts      Options
+	client    *http.Client         // Partagé par toutes les vérifications, pour réutiliser les connexions
+	soft404   []*regexp.Regexp     // Motifs compilés de Options.Soft404Patterns
+	statuses  map[uint]*linkStatus // État connu de chaque lien, protégé par mu
+	mu        sync.Mutex
+	monitored map[string]struct{} // Short codes vérifiés lors de la passe précédente, utilisé par checkUrls seulement
 }
 
 // NewUrlMonitor crée et retourne une nouvelle instance de UrlMonitor.
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
-	return &UrlMonitor{
-		linkRepo:    linkRepo,
-		interval:    interval,
-		knownStates: make(map[uint]bool),
+// Une erreur est retournée si un motif de soft-404 n'est pas une expression régulière valide.
+func NewUrlMonitor(linkRepo repository.LinkRepository, checkRepo repository.LinkCheckRepository, opts Options) (*UrlMonitor, error) {
+	opts = opts.withDefaults()
+	soft404, err := compileSoft404Patterns(opts.Soft404Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = opts.Workers * opts.PerHostConcurrency
+	transport.MaxIdleConnsPerHost = opts.PerHostConcurrency
+	transport.MaxConnsPerHost = opts.PerHostConcurrency
+	transport.TLSHandshakeTimeout = opts.Timeout
+	if !opts.AllowPrivateNetworks {
+		transport.DialContext = urlpolicy.NewDialer(opts.Timeout).DialContext
+		// Un proxy (HTTP_PROXY, HTTPS_PROXY) résoudrait et contacterait la destination à la place du dialer,
+		// qui ne verrait que l'adresse du proxy : les vérifications se connectent donc directement.
+		transport.Proxy = nil
 	}
+
+	return &UrlMonitor{
+		linkRepo:  linkRepo,
+		checkRepo: checkRepo,
+		opts:      opts,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+			// Les redirections sont suivies par checkUrl, qui enregistre la chaîne.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		soft404:  soft404,
+		statuses: make(map[uint]*linkStatus),
+	}, nil
 }
 
-// Start lance la boucle de surveillance périodique des URLs.
-func (m *UrlMonitor) Start() {
-	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
-	ticker := time.NewTicker(m.interval)
+// Start lance la boucle de surveillance périodique des URLs, jusqu'à l'annulation de ctx.
+func (m *UrlMonitor) Start(ctx context.Context) {
+	logger().Info("Starting URL monitor",
+		"interval", m.opts.Interval.String(), "workers", m.opts.Workers,
+		"per_host_concurrency", m.opts.PerHostConcurrency, "per_host_interval", m.opts.PerHostInterval.String(),
+		"jitter", m.opts.Jitter)
+
+	m.loadStatuses()
+
+	ticker := time.NewTicker(m.opts.Interval)
 	defer ticker.Stop()
 
-	m.checkUrls()
-	for range ticker.C {
-		m.checkUrls()
+	m.checkUrls(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			logger().Info("URL monitor stopped")
+			return
+		case <-ticker.C:
+			m.checkUrls(ctx)
+		}
 	}
 }
 
-// checkUrls effectue une vérification de l'état de toutes les URLs longues enregistrées.
-func (m *UrlMonitor) checkUrls() {
-	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+// loadStatuses reprend l'état de chaque lien avant l'arrêt précédent, pour signaler les changements
+// survenus entre-temps et poursuivre les séries d'échecs en cours. Une série ayant déjà atteint le seuil
+// est considérée comme déjà signalée : le redémarrage ne renvoie pas d'alerte.
+func (m *UrlMonitor) loadStatuses() {
+	states, err := m.checkRepo.LatestStates()
+	if err != nil {
+		logger().Error("Failed to load previous link states", "error", err)
+		return
+	}
+	streaks, err := m.checkRepo.FailureStreaks()
+	if err != nil {
+		logger().Error("Failed to load link failure streaks", "error", err)
+	}
 
-	links, err := m.linkRepo.GetAllLinks() // À adapter selon la signature réelle !
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for linkID, state := range states {
+		failures := streaks[linkID]
+		m.statuses[linkID] = &linkStatus{
+			state:    state,
+			failures: failures,
+			alerted:  failures >= m.opts.FailureThreshold,
+		}
+	}
+}
+
+// checkUrls effectue une passe de vérification des URLs longues des liens actifs (ni désactivés, ni en quarantaine).
+// Les vérifications sont confiées à un pool de workers, au rythme fixé par le planning de la passe.
+// Une passe en cours est interrompue à l'annulation de ctx.
+func (m *UrlMonitor) checkUrls(ctx context.Context) {
+	start := time.Now()
+	logger().Debug("Checking URLs")
+
+	links, err := m.linkRepo.GetMonitoredLinks()
 	if err != nil {
-		log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
+		logger().Error("Failed to fetch links to monitor", "error", err)
+		return
+	}
+	m.forgetRemovedLinks(links)
+
+	limiter := newHostLimiter(m.opts.PerHostConcurrency, m.opts.PerHostInterval)
+	jobs := make(chan models.Link)
+	var wg sync.WaitGroup
+	for i := 0; i < min(m.opts.Workers, len(links)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				m.checkLink(ctx, limiter, link)
+			}
+		}()
+	}
+
+	m.dispatch(ctx, links, jobs)
+	close(jobs)
+	wg.Wait()
+	if ctx.Err() != nil {
 		return
 	}
+	logger().Debug("URL check completed", "links", len(links), "duration", time.Since(start).String())
 
+	m.pruneHistory()
+}
+
+// forgetRemovedLinks retire des métriques les liens vérifiés lors de la passe précédente
+// qui ne sont plus surveillés, pour que link_up ne conserve pas leur dernier état indéfiniment.
+func (m *UrlMonitor) forgetRemovedLinks(links []models.Link) {
+	monitored := make(map[string]struct{}, len(links))
 	for _, link := range links {
-		currentState := m.isUrlAccessible(link.LongURL)
+		monitored[link.Shortcode] = struct{}{}
+	}
+	for shortCode := range m.monitored {
+		if _, ok := monitored[shortCode]; !ok {
+			m.opts.Metrics.ForgetLink(shortCode)
+		}
+	}
+	m.monitored = monitored
+}
 
-		m.mu.Lock()
-		previousState, exists := m.knownStates[link.ID]
-		m.knownStates[link.ID] = currentState
-		m.mu.Unlock()
+// dispatch transmet les liens aux workers. Avec Jitter, chaque lien reçoit un instant aléatoire
+// dans la première partie de l'intervalle, ce qui étale la charge sur toute la passe ;
+// sinon les liens sont transmis dès qu'un worker est libre.
+func (m *UrlMonitor) dispatch(ctx context.Context, links []models.Link, jobs chan<- models.Link) {
+	type scheduled struct {
+		link models.Link
+		at   time.Duration
+	}
 
-		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.Shortcode, link.LongURL, formatState(currentState))
-			continue
+	start := time.Now()
+	schedule := make([]scheduled, len(links))
+	for i, link := range links {
+		schedule[i].link = link
+		if m.opts.Jitter {
+			schedule[i].at = time.Duration(rand.Int64N(int64(float64(m.opts.Interval)*jitterSpread) + 1))
 		}
+	}
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i].at < schedule[j].at })
 
-		if previousState != currentState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.Shortcode, link.LongURL, formatState(previousState), formatState(currentState))
+	for _, s := range schedule {
+		if err := sleepUntil(ctx, start.Add(s.at)); err != nil {
+			return
+		}
+		select {
+		case jobs <- s.link:
+		case <-ctx.Done():
+			return
 		}
 	}
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
 }
 
-// isUrlAccessible effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
-func (m *UrlMonitor) isUrlAccessible(urlStr string) bool {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+// checkLink vérifie un lien dans le respect des limites de son hôte, puis enregistre le résultat.
+func (m *UrlMonitor) checkLink(ctx context.Context, limiter *hostLimiter, link models.Link) {
+	release, err := limiter.acquire(ctx, hostOf(link.LongURL))
+	if err != nil {
+		return // Passe interrompue
+	}
+	check := m.checkUrl(ctx, link.LongURL)
+	release()
+	if ctx.Err() != nil {
+		return // Vérification interrompue : l'état obtenu n'est pas significatif
+	}
+	m.recordCheck(ctx, link, check)
+}
+
+// recordCheck enregistre une vérification, journalise les changements d'état du lien,
+// bascule ses redirections vers l'URL de repli si nécessaire (voir updateRouting)
+// et notifie les pannes confirmées (FailureThreshold échecs consécutifs) ainsi que les rétablissements.
+func (m *UrlMonitor) recordCheck(ctx context.Context, link models.Link, check models.LinkCheck) {
+	m.opts.Metrics.ObserveCheck(link.Shortcode, check.Up, time.Duration(check.LatencyMs)*time.Millisecond)
+
+	check.LinkID = link.ID
+	if err := m.checkRepo.CreateCheck(&check); err != nil {
+		logger().Error("Failed to record link check", "short_code", link.Shortcode, "error", err)
+	}
+	currentState := check.State()
+
+	m.mu.Lock()
+	status, ok := m.statuses[link.ID]
+	if !ok {
+		status = &linkStatus{}
+		m.statuses[link.ID] = status
+	}
+	previousState := status.state
+	status.state = currentState
+	eventType, downSince, failures := status.observe(check, m.opts.FailureThreshold)
+	m.mu.Unlock()
+
+	m.updateRouting(link, check, failures)
+	if eventType != "" {
+		m.notify(ctx, eventType, link, check, downSince, failures)
 	}
-	resp, err := client.Head(urlStr)
+
+	if previousState == "" {
+		logger().Info("Initial link state",
+			"short_code", link.Shortcode, "long_url", link.LongURL, "state", formatState(currentState))
+		return
+	}
+
+	if previousState != currentState {
+		attrs := []any{"short_code", link.Shortcode, "long_url", link.LongURL,
+			"from", formatState(previousState), "to", formatState(currentState)}
+		if check.FinalURL != "" {
+			attrs = append(attrs, "final_url", check.FinalURL)
+		}
+		logger().Warn("Link state changed", attrs...)
+	}
+}
+
+// hostOf retourne l'hôte d'une URL, clé du limiteur par hôte (vide si l'URL est invalide).
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// pruneHistory supprime les vérifications plus anciennes que la durée de conservation de l'historique.
+func (m *UrlMonitor) pruneHistory() {
+	if m.opts.History <= 0 {
+		return
+	}
+	deleted, err := m.checkRepo.DeleteChecksBefore(time.Now().Add(-m.opts.History))
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", urlStr, err)
-		return false
+		logger().Error("Failed to prune link check history", "error", err)
+		return
+	}
+	if deleted > 0 {
+		logger().Debug("Link check history pruned", "checks", deleted)
 	}
-	defer resp.Body.Close()
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
 }
 
 // formatState est une fonction utilitaire pour rendre l'état plus lisible dans les logs.
-func formatState(accessible bool) string {
-	if accessible {
+func formatState(state string) string {
+	switch state {
+	case models.LinkStateUp:
 		return "ACCESSIBLE"
+	case models.LinkStateChanged:
+		return "CHANGED"
+	default:
+		return "INACCESSIBLE"
 	}
-	return "INACCESSIBLE"
+}
+
+// logger retourne le logger du moniteur, dérivé du logger par défaut au moment de l'appel.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "monitor")
 }
@@ -0,0 +1,257 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newTestMonitor crée un moniteur sur une base SQLite temporaire contenant un lien par URL.
+func newTestMonitor(t *testing.T, urls []string, opts Options) *UrlMonitor {
+	t.Helper()
+
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	db := repositorytest.OpenDatabase(t)
+
+	for i, u := range urls {
+		if err := db.Create(&models.Link{Shortcode: fmt.Sprintf("link%d", i), LongURL: u}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Le serveur de test écoute sur 127.0.0.1, refusé par défaut.
+	opts.AllowPrivateNetworks = true
+	m, err := NewUrlMonitor(repository.NewLinkRepository(db), repository.NewLinkCheckRepository(db), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// hitRecorder compte les requêtes simultanées reçues par le serveur de test et note leurs débuts.
+type hitRecorder struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	starts   []time.Time
+}
+
+func (h *hitRecorder) handler(delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		h.starts = append(h.starts, time.Now())
+		h.inFlight++
+		h.peak = max(h.peak, h.inFlight)
+		h.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+
+		h.mu.Lock()
+		h.inFlight--
+		h.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestCheckUrlsRespectsPerHostLimits(t *testing.T) {
+	const (
+		links       = 12
+		concurrency = 2
+		spacing     = 40 * time.Millisecond
+		// Marge pour l'ordonnancement : le serveur observe le début de la requête un peu après le limiteur.
+		tolerance = 15 * time.Millisecond
+	)
+
+	hits := &hitRecorder{}
+	server := httptest.NewServer(hits.handler(100 * time.Millisecond))
+	defer server.Close()
+
+	urls := make([]string, links)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/page/%d", server.URL, i)
+	}
+	m := newTestMonitor(t, urls, Options{
+		Interval:           time.Hour,
+		Workers:            links, // Plus de workers que la limite par hôte : seul le limiteur borne la charge
+		PerHostConcurrency: concurrency,
+		PerHostInterval:    spacing,
+	})
+
+	m.checkUrls(context.Background())
+
+	hits.mu.Lock()
+	defer hits.mu.Unlock()
+	if len(hits.starts) != links {
+		t.Fatalf("server received %d requests, want %d", len(hits.starts), links)
+	}
+	if hits.peak > concurrency {
+		t.Errorf("peak in-flight requests = %d, want at most %d", hits.peak, concurrency)
+	}
+	sort.Slice(hits.starts, func(i, j int) bool { return hits.starts[i].Before(hits.starts[j]) })
+	for i := 1; i < len(hits.starts); i++ {
+		if gap := hits.starts[i].Sub(hits.starts[i-1]); gap < spacing-tolerance {
+			t.Errorf("requests %d and %d started %s apart, want at least %s", i-1, i, gap, spacing)
+		}
+	}
+}
+
+// TestNewUrlMonitorBypassesProxies vérifie que le filtrage des adresses privées ne peut pas être
+// contourné par un proxy d'environnement, qui contacterait la destination à la place du dialer.
+func TestNewUrlMonitorBypassesProxies(t *testing.T) {
+	tests := []struct {
+		allowPrivateNetworks bool
+		wantProxy            bool
+	}{
+		{allowPrivateNetworks: false, wantProxy: false},
+		{allowPrivateNetworks: true, wantProxy: true},
+	}
+	for _, tt := range tests {
+		m, err := NewUrlMonitor(nil, nil, Options{AllowPrivateNetworks: tt.allowPrivateNetworks})
+		if err != nil {
+			t.Fatal(err)
+		}
+		transport := m.client.Transport.(*http.Transport)
+		if got := transport.Proxy != nil; got != tt.wantProxy {
+			t.Errorf("AllowPrivateNetworks=%v: proxy configured = %v, want %v", tt.allowPrivateNetworks, got, tt.wantProxy)
+		}
+	}
+}
+
+func TestCheckUrlsStopsPromptlyWhenCancelled(t *testing.T) {
+	hits := &hitRecorder{}
+	server := httptest.NewServer(hits.handler(10 * time.Second))
+	defer server.Close()
+
+	urls := make([]string, 10)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/slow/%d", server.URL, i)
+	}
+	m := newTestMonitor(t, urls, Options{
+		Interval:           time.Hour,
+		PerHostConcurrency: 2,
+		PerHostInterval:    time.Second,
+		Timeout:            30 * time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.checkUrls(ctx)
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("checkUrls did not return within 1s of cancellation")
+	}
+}
+
+// TestCheckUrlsForgetsRemovedLinks vérifie que link_up ne garde pas l'état d'un lien supprimé entre deux passes.
+func TestCheckUrlsForgetsRemovedLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	m := newTestMonitor(t, []string{server.URL + "/a", server.URL + "/b"}, Options{
+		Interval: time.Hour,
+		Metrics:  metrics.New(registry),
+	})
+
+	m.checkUrls(context.Background())
+	if got := monitoredShortCodes(t, registry); !slices.Equal(got, []string{"link0", "link1"}) {
+		t.Fatalf("link_up series after first sweep = %v, want [link0 link1]", got)
+	}
+
+	link, err := m.linkRepo.GetLinkByShortCode("link1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.linkRepo.DeleteLink(link); err != nil {
+		t.Fatal(err)
+	}
+
+	m.checkUrls(context.Background())
+	if got := monitoredShortCodes(t, registry); !slices.Equal(got, []string{"link0"}) {
+		t.Errorf("link_up series after deletion = %v, want [link0]", got)
+	}
+}
+
+func TestCheckUrlsSkipsDisabledAndQuarantinedLinks(t *testing.T) {
+	hits := &hitRecorder{}
+	server := httptest.NewServer(hits.handler(0))
+	defer server.Close()
+
+	m := newTestMonitor(t, []string{server.URL + "/active", server.URL + "/disabled", server.URL + "/quarantined"}, Options{
+		Interval: time.Hour,
+	})
+	disabled, err := m.linkRepo.GetLinkByShortCode("link1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	disabled.Disabled = true
+	if err := m.linkRepo.UpdateLink(disabled); err != nil {
+		t.Fatal(err)
+	}
+	quarantined, err := m.linkRepo.GetLinkByShortCode("link2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.linkRepo.QuarantineLink(quarantined.ID, "malware.example", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	m.checkUrls(context.Background())
+
+	// Une destination bloquée ne doit plus être contactée, même pour une simple vérification.
+	hits.mu.Lock()
+	defer hits.mu.Unlock()
+	if len(hits.starts) != 1 {
+		t.Errorf("server received %d requests, want 1 (only the active link)", len(hits.starts))
+	}
+}
+
+// monitoredShortCodes retourne les short codes des séries link_up du registre, triés.
+func monitoredShortCodes(t *testing.T, registry *prometheus.Registry) []string {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []string
+	for _, family := range families {
+		if family.GetName() != "urlshortener_link_up" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "short_code" {
+					codes = append(codes, label.GetValue())
+				}
+			}
+		}
+	}
+	slices.Sort(codes)
+	return codes
+}
@@ -0,0 +1,142 @@
+// Package notify envoie les alertes du moniteur d'URLs (lien inaccessible, lien rétabli)
+// vers des canaux externes : webhooks HTTP signés et e-mails SMTP.
+package notify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+)
+
+// Types d'événements notifiés.
+const (
+	EventLinkDown      = "link_down"      // La destination d'un lien a échoué plusieurs vérifications consécutives
+	EventLinkRecovered = "link_recovered" // La destination d'un lien signalée inaccessible répond de nouveau
+)
+
+// Event décrit un changement d'état d'un lien à notifier. C'est aussi le corps JSON envoyé aux webhooks.
+type Event struct {
+	Type                string     `json:"event"`
+	LinkID              uint       `json:"link_id"`
+	ShortCode           string     `json:"short_code"`
+	LongURL             string     `json:"long_url"`
+	OccurredAt          time.Time  `json:"occurred_at"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"` // Échecs consécutifs ayant déclenché l'alerte
+	DownSince           *time.Time `json:"down_since,omitempty"`           // Premier échec de la série (si connu)
+	StatusCode          int        `json:"status_code,omitempty"`          // Code HTTP de la dernière vérification
+	ErrorClass          string     `json:"error,omitempty"`                // Classe d'erreur de la dernière vérification
+	FinalURL            string     `json:"final_url,omitempty"`            // URL finale après redirections
+}
+
+// Notifier est un canal de notification. Notify retourne une erreur si l'événement n'a pas pu être remis.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// Valeurs par défaut du Dispatcher.
+const (
+	DefaultQueueSize     = 100
+	DefaultNotifyTimeout = 30 * time.Second
+)
+
+// ErrDispatcherStopped est retournée par Notify après l'arrêt du Dispatcher.
+var ErrDispatcherStopped = errors.New("notification dispatcher is stopped")
+
+// Dispatcher transmet les événements à plusieurs notifiers, en arrière-plan :
+// Notify ne bloque jamais le moniteur, même lorsqu'un webhook est lent ou réessaie.
+// Les événements sont abandonnés (et journalisés) si la file est pleine.
+type Dispatcher struct {
+	notifiers []Notifier
+	metrics   *metrics.Metrics
+	timeout   time.Duration
+	queue     chan Event
+
+	mu      sync.RWMutex // Protège l'envoi dans queue contre sa fermeture par Stop
+	stopped bool
+	done    chan struct{}
+}
+
+// NewDispatcher crée un Dispatcher vers les notifiers donnés et lance sa goroutine d'envoi.
+// Les envois sont comptés dans m (nil = non mesurés).
+func NewDispatcher(m *metrics.Metrics, notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{
+		notifiers: notifiers,
+		metrics:   m,
+		timeout:   DefaultNotifyTimeout,
+		queue:     make(chan Event, DefaultQueueSize),
+		done:      make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Name implémente Notifier.
+func (d *Dispatcher) Name() string {
+	return "dispatcher"
+}
+
+// Notify met l'événement en file pour tous les notifiers, sans attendre son envoi.
+func (d *Dispatcher) Notify(_ context.Context, event Event) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return ErrDispatcherStopped
+	}
+
+	select {
+	case d.queue <- event:
+		return nil
+	default:
+		logger().Warn("Notification queue is full, dropping event",
+			"event", event.Type, "short_code", event.ShortCode)
+		return errors.New("notification queue is full")
+	}
+}
+
+// Stop cesse d'accepter des événements et attend l'envoi de ceux en file, au plus jusqu'à l'annulation de ctx.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.stopped {
+		d.stopped = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run envoie les événements de la file à chaque notifier, dans l'ordre de réception.
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for event := range d.queue {
+		for _, notifier := range d.notifiers {
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+			err := notifier.Notify(ctx, event)
+			cancel()
+
+			d.metrics.NotificationSent(notifier.Name(), err)
+			if err != nil {
+				logger().Error("Failed to send notification",
+					"notifier", notifier.Name(), "event", event.Type, "short_code", event.ShortCode, "error", err)
+				continue
+			}
+			logger().Info("Notification sent",
+				"notifier", notifier.Name(), "event", event.Type, "short_code", event.ShortCode)
+		}
+	}
+}
+
+// logger retourne le logger des notifications, dérivé du logger par défaut au moment de l'appel.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "notify")
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPOptions configure l'envoi des notifications par e-mail.
+type SMTPOptions struct {
+	Host     string   // Serveur SMTP
+	Port     int      // Port de soumission (587 par défaut, STARTTLS utilisé si le serveur le propose)
+	Username string   // Identifiant (authentification PLAIN, désactivée si vide)
+	Password string   // Mot de passe
+	From     string   // Adresse d'expédition
+	To       []string // Destinataires
+}
+
+// SMTPNotifier envoie chaque événement par e-mail, en texte brut.
+type SMTPNotifier struct {
+	opts SMTPOptions
+}
+
+// NewSMTPNotifier crée un SMTPNotifier. Host, From et au moins un destinataire sont obligatoires.
+func NewSMTPNotifier(opts SMTPOptions) (*SMTPNotifier, error) {
+	if opts.Host == "" || opts.From == "" || len(opts.To) == 0 {
+		return nil, errors.New("smtp notifier requires host, from and at least one recipient")
+	}
+	if opts.Port == 0 {
+		opts.Port = 587
+	}
+	return &SMTPNotifier{opts: opts}, nil
+}
+
+// Name implémente Notifier.
+func (s *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+// Notify implémente Notifier. L'envoi complet (connexion comprise) est limité par ctx.
+func (s *SMTPNotifier) Notify(ctx context.Context, event Event) error {
+	addr := net.JoinHostPort(s.opts.Host, fmt.Sprint(s.opts.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server %s: %w", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.opts.Host)
+	if err != nil {
+		return fmt.Errorf("failed to start smtp session with %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.opts.Host}); err != nil {
+			return fmt.Errorf("failed to start tls with %s: %w", addr, err)
+		}
+	}
+	if s.opts.Username != "" {
+		auth := smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate with %s: %w", addr, err)
+		}
+	}
+
+	if err := client.Mail(s.opts.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM rejected: %w", err)
+	}
+	for _, to := range s.opts.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s rejected: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA rejected: %w", err)
+	}
+	if _, err := w.Write(s.message(event)); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected email: %w", err)
+	}
+	return client.Quit()
+}
+
+// message construit l'e-mail (en-têtes et corps) décrivant un événement.
+func (s *SMTPNotifier) message(event Event) []byte {
+	subject, lines := describe(event)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.opts.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.opts.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.OccurredAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	for _, line := range lines {
+		msg.WriteString(line + "\r\n")
+	}
+	return msg.Bytes()
+}
+
+// describe retourne le sujet et les lignes du corps de l'e-mail d'un événement.
+func describe(event Event) (string, []string) {
+	var subject, summary string
+	switch event.Type {
+	case EventLinkDown:
+		subject = fmt.Sprintf("[url-shortener] Lien %s inaccessible", event.ShortCode)
+		summary = fmt.Sprintf("La destination du lien %s ne répond plus (%d vérification(s) consécutive(s) en échec).",
+			event.ShortCode, event.ConsecutiveFailures)
+	case EventLinkRecovered:
+		subject = fmt.Sprintf("[url-shortener] Lien %s rétabli", event.ShortCode)
+		summary = fmt.Sprintf("La destination du lien %s répond de nouveau.", event.ShortCode)
+	default:
+		subject = fmt.Sprintf("[url-shortener] %s : %s", event.ShortCode, event.Type)
+		summary = fmt.Sprintf("Événement %s sur le lien %s.", event.Type, event.ShortCode)
+	}
+
+	lines := []string{summary, "", "URL longue : " + event.LongURL}
+	if event.DownSince != nil {
+		lines = append(lines, "Inaccessible depuis : "+event.DownSince.Format(time.RFC3339))
+	}
+	if event.StatusCode != 0 {
+		lines = append(lines, fmt.Sprintf("Code HTTP : %d", event.StatusCode))
+	}
+	if event.ErrorClass != "" {
+		lines = append(lines, "Erreur : "+event.ErrorClass)
+	}
+	if event.FinalURL != "" {
+		lines = append(lines, "URL finale : "+event.FinalURL)
+	}
+	lines = append(lines, "Date : "+event.OccurredAt.Format(time.RFC3339))
+	return subject, lines
+}
@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn est un serveur SMTP minimal (sans STARTTLS ni authentification)
+// qui accepte une session et enregistre l'enveloppe et le contenu du message reçu.
+type smtpStandIn struct {
+	listener net.Listener
+	done     chan struct{}
+
+	from    string
+	rcpts   []string
+	message string
+}
+
+func startSMTPStandIn(t *testing.T) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{listener: listener, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 stand-in ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-stand-in")
+			reply("250 8BITMIME")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			s.rcpts = append(s.rcpts, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.message = data.String()
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	standIn := startSMTPStandIn(t)
+	host, portString, _ := net.SplitHostPort(standIn.listener.Addr().String())
+	port, _ := strconv.Atoi(portString)
+
+	notifier, err := NewSMTPNotifier(SMTPOptions{
+		Host: host,
+		Port: port,
+		From: "monitor@example.com",
+		To:   []string{"ops@example.com", "oncall@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	downSince := time.Date(2025, 6, 1, 9, 30, 0, 0, time.UTC)
+	event := Event{
+		Type:                EventLinkDown,
+		ShortCode:           "promo",
+		LongURL:             "https://example.com/promo",
+		OccurredAt:          downSince.Add(10 * time.Minute),
+		ConsecutiveFailures: 3,
+		DownSince:           &downSince,
+		StatusCode:          503,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Notify(ctx, event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	<-standIn.done
+
+	if !strings.Contains(standIn.from, "<monitor@example.com>") {
+		t.Errorf("MAIL command = %q", standIn.from)
+	}
+	if len(standIn.rcpts) != 2 || !strings.Contains(standIn.rcpts[1], "<oncall@example.com>") {
+		t.Errorf("RCPT commands = %q", standIn.rcpts)
+	}
+	for _, want := range []string{
+		"To: ops@example.com, oncall@example.com\r\n",
+		"Subject: [url-shortener] Lien promo inaccessible\r\n",
+		"URL longue : https://example.com/promo\r\n",
+		"Inaccessible depuis : 2025-06-01T09:30:00Z\r\n",
+		"Code HTTP : 503\r\n",
+	} {
+		if !strings.Contains(standIn.message, want) {
+			t.Errorf("message does not contain %q:\n%s", want, standIn.message)
+		}
+	}
+}
+
+func TestSMTPNotifierConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	listener.Close() // Plus rien n'écoute sur ce port
+
+	notifier, err := NewSMTPNotifier(SMTPOptions{Host: "127.0.0.1", Port: addr.Port, From: "a@example.com", To: []string{"b@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), Event{Type: EventLinkDown}); err == nil {
+		t.Error("Notify() succeeded without an smtp server")
+	}
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// En-têtes ajoutés aux requêtes des webhooks.
+const (
+	SignatureHeader = "X-Signature-256" // "sha256=" suivi du HMAC-SHA256 hexadécimal de "<timestamp>.<corps>"
+	TimestampHeader = "X-Timestamp"     // Instant d'envoi (secondes Unix), inclus dans la signature contre le rejeu
+	EventHeader     = "X-Event"         // Type d'événement, pour router sans lire le corps
+)
+
+// Valeurs par défaut des webhooks.
+const (
+	DefaultWebhookRetries = 3
+	DefaultWebhookBackoff = time.Second
+)
+
+// WebhookNotifier envoie chaque événement en JSON (POST) à une URL.
+// Si un secret est configuré, la requête est signée (SignatureHeader) pour que le destinataire puisse
+// vérifier son origine. Les erreurs réseau et les réponses 429 ou 5xx sont réessayées, avec un délai doublé
+// à chaque tentative ; les autres réponses 4xx sont définitives.
+type WebhookNotifier struct {
+	url     string
+	secret  []byte
+	retries int
+	backoff time.Duration
+	client  *http.Client
+}
+
+// NewWebhookNotifier crée un WebhookNotifier. retries est le nombre de nouvelles tentatives après un échec
+// (DefaultWebhookRetries si négatif).
+func NewWebhookNotifier(url, secret string, retries int) *WebhookNotifier {
+	if retries < 0 {
+		retries = DefaultWebhookRetries
+	}
+	return &WebhookNotifier{
+		url:     url,
+		secret:  []byte(secret),
+		retries: retries,
+		backoff: DefaultWebhookBackoff,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implémente Notifier.
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify implémente Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.send(ctx, event.Type, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.retries {
+			return fmt.Errorf("failed to deliver webhook to %s after %d attempt(s): %w", w.url, attempt+1, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to deliver webhook to %s: %w", w.url, ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// send effectue une tentative d'envoi. retry indique si l'échec éventuel justifie une nouvelle tentative.
+func (w *WebhookNotifier) send(ctx context.Context, eventType string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if len(w.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, timestamp, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10)) // Permet la réutilisation de la connexion
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+}
+
+// Sign calcule la signature d'un corps de webhook : HMAC-SHA256 hexadécimal de "<timestamp>.<corps>".
+// Les destinataires la recalculent avec le secret partagé et la comparent à l'en-tête SignatureHeader.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
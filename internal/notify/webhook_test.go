@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// Vecteur calculé indépendamment : HMAC-SHA256("hooksecret", "1700000000.{"type":"link_down"}").
+	const want = "46b9c2418e6f93a65aa7801a2ef26ee6a050d0677e63ac6cb0e600c1e7db00e4"
+	if got := Sign([]byte("hooksecret"), "1700000000", []byte(`{"type":"link_down"}`)); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
+
+// webhookStandIn est un destinataire de webhooks de test : il répond successivement les codes de statuses
+// (puis 200) et vérifie la signature de chaque requête.
+type webhookStandIn struct {
+	t        *testing.T
+	secret   string
+	statuses []int
+
+	mu       sync.Mutex
+	requests int
+	events   []Event
+}
+
+func (s *webhookStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	timestamp := r.Header.Get(TimestampHeader)
+	signature := strings.TrimPrefix(r.Header.Get(SignatureHeader), "sha256=")
+	if !hmac.Equal([]byte(signature), []byte(Sign([]byte(s.secret), timestamp, body))) {
+		s.t.Errorf("invalid signature %q for timestamp %q", signature, timestamp)
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		s.t.Errorf("invalid webhook body %q: %v", body, err)
+	}
+	if got := r.Header.Get(EventHeader); got != event.Type {
+		s.t.Errorf("%s = %q, want %q", EventHeader, got, event.Type)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := http.StatusOK
+	if s.requests < len(s.statuses) {
+		status = s.statuses[s.requests]
+	}
+	s.requests++
+	s.events = append(s.events, event)
+	w.WriteHeader(status)
+}
+
+func TestWebhookNotifierRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      bool
+		wantRequests int
+	}{
+		{"delivered first time", nil, false, 1},
+		{"503 then 200 is retried", []int{http.StatusServiceUnavailable}, false, 2},
+		{"429 is retried", []int{http.StatusTooManyRequests, http.StatusBadGateway}, false, 3},
+		{"4xx is not retried", []int{http.StatusBadRequest}, true, 1},
+		{"gives up after retries", []int{500, 500, 500, 500, 500}, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standIn := &webhookStandIn{t: t, secret: "hooksecret", statuses: tt.statuses}
+			server := httptest.NewServer(standIn)
+			defer server.Close()
+
+			notifier := NewWebhookNotifier(server.URL, "hooksecret", 2)
+			notifier.backoff = time.Millisecond
+
+			event := Event{Type: EventLinkDown, LinkID: 7, ShortCode: "promo", LongURL: "https://example.com", ConsecutiveFailures: 3}
+			err := notifier.Notify(context.Background(), event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			standIn.mu.Lock()
+			defer standIn.mu.Unlock()
+			if standIn.requests != tt.wantRequests {
+				t.Errorf("webhook received %d requests, want %d", standIn.requests, tt.wantRequests)
+			}
+			if got := standIn.events[0]; got.ShortCode != "promo" || got.ConsecutiveFailures != 3 {
+				t.Errorf("webhook received %+v", got)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierStopsRetryingOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, "", 5)
+	notifier.backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := notifier.Notify(ctx, Event{Type: EventLinkDown}); err == nil {
+		t.Fatal("Notify() succeeded, want an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Notify() returned after %s, want it to stop at cancellation", elapsed)
+	}
+}
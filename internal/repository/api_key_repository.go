@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository définit les méthodes d'accès aux clés d'API.
+type APIKeyRepository interface {
+	CreateKey(key *models.APIKey) error
+	GetKeyByHash(hash string) (*models.APIKey, error)
+	GetKeyByID(id uint) (*models.APIKey, error)
+	ListKeys() ([]models.APIKey, error)
+	RevokeKey(key *models.APIKey, at time.Time) error
+	TouchKey(id uint, at time.Time) error
+}
+
+// GormAPIKeyRepository est l'implémentation de APIKeyRepository utilisant GORM.
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository crée et retourne une nouvelle instance de GormAPIKeyRepository.
+func NewAPIKeyRepository(db *gorm.DB) *GormAPIKeyRepository {
+	return &GormAPIKeyRepository{db: db}
+}
+
+// CreateKey insère une nouvelle clé d'API.
+func (r *GormAPIKeyRepository) CreateKey(key *models.APIKey) error {
+	if err := r.db.Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetKeyByHash récupère une clé d'API par l'empreinte de son secret.
+// Il renvoie gorm.ErrRecordNotFound si aucune clé ne correspond.
+func (r *GormAPIKeyRepository) GetKeyByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("hash = ?", hash).First(&key).Error; err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetKeyByID récupère une clé d'API par son identifiant.
+func (r *GormAPIKeyRepository) GetKeyByID(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.First(&key, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get api key %d: %w", id, err)
+	}
+	return &key, nil
+}
+
+// ListKeys retourne toutes les clés d'API, révoquées comprises, de la plus ancienne à la plus récente.
+func (r *GormAPIKeyRepository) ListKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.Order("id").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey révoque une clé d'API : elle est conservée pour l'historique mais n'est plus acceptée.
+func (r *GormAPIKeyRepository) RevokeKey(key *models.APIKey, at time.Time) error {
+	if err := r.db.Model(key).UpdateColumn("revoked_at", at).Error; err != nil {
+		return fmt.Errorf("failed to revoke api key %d: %w", key.ID, err)
+	}
+	key.RevokedAt = &at
+	return nil
+}
+
+// TouchKey enregistre la date de dernière utilisation d'une clé d'API.
+func (r *GormAPIKeyRepository) TouchKey(id uint, at time.Time) error {
+	if err := r.db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error; err != nil {
+		return fmt.Errorf("failed to record use of api key %d: %w", id, err)
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
@@ -11,7 +14,105 @@ import (
 // pour les opérations CRUD sur les clics.
 type ClickRepository interface {
 	CreateClick(click *models.Click) error
-	CountClicksByLinkID(linkID uint) (int, error)
+	CreateClicks(clicks []models.Click) error
+	CountClicksByLinkID(linkID uint, filter ClickFilter) (int, error)
+	TopReferrers(linkID uint, filter ClickFilter, limit int) ([]ReferrerCount, error)
+	CountClicksByHour(linkID uint, filter ClickFilter) ([]HourlyCount, error)
+	CountClicksByDimension(linkID uint, dimension string, filter ClickFilter) ([]DimensionCount, error)
+	RollupClicksBefore(cutoff time.Time, batchSize int) (int, error)
+}
+
+// ClickFilter restreint les requêtes d'analytics à une période donnée.
+// Une borne à zéro n'est pas appliquée. From est inclusif, To exclusif.
+// Les clics de robots sont exclus sauf si IncludeBots vaut true.
+// Country restreint optionnellement les clics à un pays (code ISO).
+type ClickFilter struct {
+	From        time.Time
+	To          time.Time
+	IncludeBots bool
+	Country     string
+}
+
+// apply ajoute les conditions du filtre à une requête portant sur la table 'clicks'.
+func (f ClickFilter) apply(tx *gorm.DB) *gorm.DB {
+	if !f.IncludeBots {
+		tx = tx.Where("is_bot = ?", false)
+	}
+	if f.Country != "" {
+		tx = tx.Where("country = ?", f.Country)
+	}
+	if !f.From.IsZero() {
+		tx = tx.Where("timestamp >= ?", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		tx = tx.Where("timestamp < ?", f.To.UTC())
+	}
+	return tx
+}
+
+// rollupDayLayout est le format de la colonne 'day' de la table 'click_rollups'.
+const rollupDayLayout = "2006-01-02"
+
+// applyRollups ajoute les conditions du filtre à une requête portant sur la table 'click_rollups'.
+// Un agrégat journalier est retenu dès que sa journée UTC chevauche la période.
+// Les agrégats ne conservent pas le pays : ils sont ignorés (ok = false) lorsque Country est renseigné.
+func (f ClickFilter) applyRollups(tx *gorm.DB) (result *gorm.DB, ok bool) {
+	if f.Country != "" {
+		return tx, false
+	}
+	if !f.From.IsZero() {
+		tx = tx.Where("day >= ?", f.From.UTC().Format(rollupDayLayout))
+	}
+	if !f.To.IsZero() {
+		tx = tx.Where("day <= ?", f.To.UTC().Add(-time.Nanosecond).Format(rollupDayLayout))
+	}
+	return tx, true
+}
+
+// rollupClicksExpr est l'expression SQL du nombre de clics d'un agrégat journalier selon le filtre.
+func (f ClickFilter) rollupClicksExpr() string {
+	if f.IncludeBots {
+		return "clicks + bot_clicks"
+	}
+	return "clicks"
+}
+
+// HourlyCount est le nombre de clics d'un lien pendant une heure UTC.
+type HourlyCount struct {
+	Hour   time.Time
+	Clicks int
+}
+
+// Dimensions acceptées par CountClicksByDimension, qui correspondent aux colonnes de la table 'clicks'.
+const (
+	DimensionBrowser    = "browser"
+	DimensionOS         = "os"
+	DimensionDeviceType = "device_type"
+	DimensionCountry    = "country"
+	DimensionRegion     = "region"
+	DimensionCity       = "city"
+)
+
+// allowedDimensions protège la requête de regroupement : seules ces colonnes peuvent être interpolées.
+var allowedDimensions = map[string]bool{
+	DimensionBrowser:    true,
+	DimensionOS:         true,
+	DimensionDeviceType: true,
+	DimensionCountry:    true,
+	DimensionRegion:     true,
+	DimensionCity:       true,
+}
+
+// DimensionCount associe une valeur d'une dimension (navigateur, OS...) à son nombre de clics.
+type DimensionCount struct {
+	Value  string
+	Clicks int
+}
+
+// ReferrerCount associe un domaine référent à son nombre de clics.
+type ReferrerCount struct {
+	Domain string
+	Clicks int
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
@@ -26,22 +127,213 @@ func NewClickRepository(db *gorm.DB) *GormClickRepository {
 }
 
 // CreateClick insère un nouvel enregistrement de clic dans la base de données.
-// Elle reçoit un pointeur vers une structure models.Click et la persiste en utilisant GORM.
+// Elle reçoit un pointeur vers une structure models.Click et la persiste en utilisant GORM,
+// avec le compteur de clics du lien dans la même transaction.
 func (r *GormClickRepository) CreateClick(click *models.Click) error {
-	if err := r.db.Create(click).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(click).Error; err != nil {
+			return err
+		}
+		return incrementClickCounts(tx, []models.Click{*click})
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create click: %w", err)
 	}
 	return nil
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
+// CreateClicks insère un lot de clics en une seule transaction (INSERT multi-lignes)
+// et met à jour le compteur de clics des liens concernés dans cette même transaction.
+// C'est la méthode utilisée par les workers, qui regroupent les clics avant de les persister.
+func (r *GormClickRepository) CreateClicks(clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(clicks, len(clicks)).Error; err != nil {
+			return err
+		}
+		return incrementClickCounts(tx, clicks)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %d clicks: %w", len(clicks), err)
+	}
+	return nil
+}
+
+// incrementClickCounts ajoute les clics humains du lot à la colonne click_count de leurs liens.
+// Les liens supprimés logiquement sont aussi mis à jour, pour que leur compteur reste juste s'ils sont restaurés.
+func incrementClickCounts(tx *gorm.DB, clicks []models.Click) error {
+	counts := make(map[uint]int)
+	for _, click := range clicks {
+		if !click.IsBot {
+			counts[click.LinkID]++
+		}
+	}
+	for _, linkID := range slices.Sorted(maps.Keys(counts)) {
+		err := tx.Unscoped().Model(&models.Link{}).
+			Where("id = ?", linkID).
+			UpdateColumn("click_count", gorm.Expr("click_count + ?", counts[linkID])).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné, sur la période du filtre.
 // Cette méthode est utilisée pour fournir des statistiques pour une URL courte.
-func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
+// Les clics déjà agrégés par la politique de rétention sont inclus.
+func (r *GormClickRepository) CountClicksByLinkID(linkID uint, filter ClickFilter) (int, error) {
 	var count int64 // GORM retourne un int64 pour les décomptes
 
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+	tx := r.db.Model(&models.Click{}).Where("link_id = ?", linkID)
+	if err := filter.apply(tx).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count clicks for link ID %d: %w", linkID, err)
 	}
 
+	rollupTx, ok := filter.applyRollups(r.db.Model(&models.ClickRollup{}).Where("link_id = ?", linkID))
+	if ok {
+		var rolledUp int64
+		err := rollupTx.Select("COALESCE(SUM(" + filter.rollupClicksExpr() + "), 0)").Scan(&rolledUp).Error
+		if err != nil {
+			return 0, fmt.Errorf("failed to count rolled up clicks for link ID %d: %w", linkID, err)
+		}
+		count += rolledUp
+	}
+
 	return int(count), nil // Convert the int64 count to an int
 }
+
+// TopReferrers retourne les domaines référents les plus fréquents d'un lien sur la période du filtre,
+// triés par nombre de clics décroissant. Les accès directs sont regroupés sous un domaine vide.
+func (r *GormClickRepository) TopReferrers(linkID uint, filter ClickFilter, limit int) ([]ReferrerCount, error) {
+	var referrers []ReferrerCount
+
+	tx := r.db.Model(&models.Click{}).
+		Select("COALESCE(referrer_domain, '') AS domain, COUNT(*) AS clicks").
+		Where("link_id = ?", linkID)
+	err := filter.apply(tx).
+		Group("domain").
+		Order("clicks DESC, domain ASC").
+		Limit(limit).
+		Scan(&referrers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top referrers for link ID %d: %w", linkID, err)
+	}
+	return referrers, nil
+}
+
+// sqliteHourLayout est le format produit par strftime('%Y-%m-%d %H:00:00', ...) en SQLite.
+const sqliteHourLayout = "2006-01-02 15:04:05"
+
+// CountClicksByHour agrège les clics d'un lien par heure UTC sur la période du filtre.
+// L'agrégation est faite par la base ; le regroupement final (jour, semaine, fuseau horaire)
+// est laissé à l'appelant, ce qui évite de charger chaque clic en mémoire.
+// Les clics agrégés par la politique de rétention n'ont qu'une résolution journalière :
+// ils sont rattachés à minuit UTC de leur journée.
+func (r *GormClickRepository) CountClicksByHour(linkID uint, filter ClickFilter) ([]HourlyCount, error) {
+	var rows []struct {
+		Hour   string
+		Clicks int
+	}
+
+	tx := r.db.Model(&models.Click{}).
+		Select("strftime('%Y-%m-%d %H:00:00', timestamp) AS hour, COUNT(*) AS clicks").
+		Where("link_id = ?", linkID)
+	err := filter.apply(tx).
+		Group("hour").
+		Order("hour").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count hourly clicks for link ID %d: %w", linkID, err)
+	}
+
+	counts := make([]HourlyCount, 0, len(rows))
+	for _, row := range rows {
+		hour, err := time.ParseInLocation(sqliteHourLayout, row.Hour, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse click hour %q for link ID %d: %w", row.Hour, linkID, err)
+		}
+		counts = append(counts, HourlyCount{Hour: hour, Clicks: row.Clicks})
+	}
+
+	rollupTx, ok := filter.applyRollups(r.db.Model(&models.ClickRollup{}).Where("link_id = ?", linkID))
+	if !ok {
+		return counts, nil
+	}
+	var rollups []struct {
+		Day    string
+		Clicks int
+	}
+	err = rollupTx.Select("day, " + filter.rollupClicksExpr() + " AS clicks").Order("day").Scan(&rollups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rolled up clicks for link ID %d: %w", linkID, err)
+	}
+	for _, rollup := range rollups {
+		day, err := time.ParseInLocation(rollupDayLayout, rollup.Day, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rollup day %q for link ID %d: %w", rollup.Day, linkID, err)
+		}
+		counts = append(counts, HourlyCount{Hour: day, Clicks: rollup.Clicks})
+	}
+	return counts, nil
+}
+
+// CountClicksByDimension répartit les clics d'un lien selon une dimension (navigateur, OS, appareil, pays...),
+// triée par nombre de clics décroissant.
+func (r *GormClickRepository) CountClicksByDimension(linkID uint, dimension string, filter ClickFilter) ([]DimensionCount, error) {
+	if !allowedDimensions[dimension] {
+		return nil, fmt.Errorf("unknown click dimension %q", dimension)
+	}
+
+	// COALESCE regroupe les NULL des clics antérieurs à l'ajout de la colonne avec les valeurs vides.
+	var counts []DimensionCount
+	tx := r.db.Model(&models.Click{}).
+		Select("COALESCE("+dimension+", '') AS value, COUNT(*) AS clicks").
+		Where("link_id = ?", linkID)
+	err := filter.apply(tx).
+		Group("value").
+		Order("clicks DESC, value ASC").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count clicks by %s for link ID %d: %w", dimension, linkID, err)
+	}
+	return counts, nil
+}
+
+// RollupClicksBefore agrège par lien et par jour UTC au plus batchSize clics antérieurs à cutoff,
+// les ajoute à la table 'click_rollups' puis les supprime de 'clicks', dans une même transaction.
+// Elle retourne le nombre de clics traités : l'appelant répète l'opération tant qu'il reste des clics,
+// ce qui borne la durée de chaque verrou d'écriture sur la base.
+func (r *GormClickRepository) RollupClicksBefore(cutoff time.Time, batchSize int) (int, error) {
+	var ids []uint
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Click{}).
+			Where("timestamp < ?", cutoff.UTC()).
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO click_rollups (link_id, day, clicks, bot_clicks)
+			SELECT link_id, date(timestamp) AS day,
+				SUM(CASE WHEN is_bot THEN 0 ELSE 1 END), SUM(CASE WHEN is_bot THEN 1 ELSE 0 END)
+			FROM clicks WHERE id IN ? GROUP BY link_id, day
+			ON CONFLICT (link_id, day) DO UPDATE SET
+				clicks = clicks + excluded.clicks,
+				bot_clicks = bot_clicks + excluded.bot_clicks`, ids).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ?", ids).Delete(&models.Click{}).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up clicks before %s: %w", cutoff.Format(time.RFC3339), err)
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// LinkCheckRepository est une interface qui définit les méthodes d'accès aux données
+// pour l'historique des vérifications d'URL du moniteur.
+type LinkCheckRepository interface {
+	CreateCheck(check *models.LinkCheck) error
+	LatestChecks(linkID uint, limit int) ([]models.LinkCheck, error)
+	CountChecks(linkID uint, from, to time.Time) (CheckCounts, error)
+	LatestStates() (map[uint]string, error)
+	FailureStreaks() (map[uint]int, error)
+	DeleteChecksBefore(cutoff time.Time) (int64, error)
+}
+
+// CheckCounts est le nombre de vérifications d'un lien sur une période, dont celles où l'URL était accessible.
+type CheckCounts struct {
+	Total int
+	Up    int
+}
+
+// GormLinkCheckRepository est l'implémentation de LinkCheckRepository utilisant GORM.
+type GormLinkCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewLinkCheckRepository crée et retourne une nouvelle instance de GormLinkCheckRepository.
+func NewLinkCheckRepository(db *gorm.DB) *GormLinkCheckRepository {
+	return &GormLinkCheckRepository{db: db}
+}
+
+// CreateCheck enregistre le résultat d'une vérification.
+func (r *GormLinkCheckRepository) CreateCheck(check *models.LinkCheck) error {
+	if err := r.db.Create(check).Error; err != nil {
+		return fmt.Errorf("failed to create link check: %w", err)
+	}
+	return nil
+}
+
+// LatestChecks retourne les limit dernières vérifications d'un lien, de la plus récente à la plus ancienne.
+func (r *GormLinkCheckRepository) LatestChecks(linkID uint, limit int) ([]models.LinkCheck, error) {
+	var checks []models.LinkCheck
+	err := r.db.Where("link_id = ?", linkID).
+		Order("checked_at DESC, id DESC").
+		Limit(limit).
+		Find(&checks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest checks for link ID %d: %w", linkID, err)
+	}
+	return checks, nil
+}
+
+// CountChecks compte les vérifications d'un lien entre from (inclus) et to (exclu).
+// Une borne à zéro n'est pas appliquée.
+func (r *GormLinkCheckRepository) CountChecks(linkID uint, from, to time.Time) (CheckCounts, error) {
+	var counts CheckCounts
+
+	tx := r.db.Model(&models.LinkCheck{}).Where("link_id = ?", linkID)
+	if !from.IsZero() {
+		tx = tx.Where("checked_at >= ?", from.UTC())
+	}
+	if !to.IsZero() {
+		tx = tx.Where("checked_at < ?", to.UTC())
+	}
+	err := tx.Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN up THEN 1 ELSE 0 END), 0) AS up").
+		Scan(&counts).Error
+	if err != nil {
+		return counts, fmt.Errorf("failed to count checks for link ID %d: %w", linkID, err)
+	}
+	return counts, nil
+}
+
+// LatestStates retourne l'état (models.LinkState*) de la dernière vérification de chaque lien.
+// Le moniteur s'en sert au démarrage pour détecter les changements d'état survenus pendant un redémarrage.
+func (r *GormLinkCheckRepository) LatestStates() (map[uint]string, error) {
+	var rows []models.LinkCheck
+	latest := r.db.Model(&models.LinkCheck{}).Select("MAX(id)").Group("link_id")
+	err := r.db.Model(&models.LinkCheck{}).
+		Select("link_id, up, host_changed").
+		Where("id IN (?)", latest).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest link states: %w", err)
+	}
+
+	states := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		states[row.LinkID] = row.State()
+	}
+	return states, nil
+}
+
+// FailureStreaks retourne, pour chaque lien dont la dernière vérification a échoué,
+// le nombre de vérifications en échec depuis sa dernière vérification réussie.
+func (r *GormLinkCheckRepository) FailureStreaks() (map[uint]int, error) {
+	var rows []struct {
+		LinkID   uint
+		Failures int
+	}
+	err := r.db.Model(&models.LinkCheck{}).
+		Select("link_id, COUNT(*) AS failures").
+		Where("up = ?", false).
+		Where("id > COALESCE((SELECT MAX(ok.id) FROM link_checks ok WHERE ok.link_id = link_checks.link_id AND ok.up = ?), 0)", true).
+		Group("link_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link failure streaks: %w", err)
+	}
+
+	streaks := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		streaks[row.LinkID] = row.Failures
+	}
+	return streaks, nil
+}
+
+// DeleteChecksBefore supprime les vérifications antérieures à cutoff et retourne le nombre de lignes supprimées.
+func (r *GormLinkCheckRepository) DeleteChecksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("checked_at < ?", cutoff.UTC()).Delete(&models.LinkCheck{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete link checks before %s: %w", cutoff.Format(time.RFC3339), result.Error)
+	}
+	return result.RowsAffected, nil
+}
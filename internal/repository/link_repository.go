@@ -2,6 +2,8 @@ package repository
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
@@ -12,8 +14,20 @@ import (
 type LinkRepository interface {
 	CreateLink(link *models.Link) error
 	GetLinkByShortCode(shortCode string) (*models.Link, error)
+	GetLinkByShortCodeUnscoped(shortCode string) (*models.Link, error)
+	UpdateLink(link *models.Link) error
+	DeleteLink(link *models.Link) error
+	RestoreLink(link *models.Link) error
 	GetAllLinks() ([]models.Link, error)
-	CountClicksByLinkID(linkID uint) (int, error)
+	GetMonitoredLinks() ([]models.Link, error)
+	ListLinks(query LinkListQuery) ([]models.Link, error)
+	CountClicksByLinkID(linkID uint, includeBots bool) (int, error)
+	ConsumeClick(linkID uint) (bool, error)
+	SetRouting(linkID uint, event *models.LinkRoutingEvent) (bool, error)
+	GetRoutingEvents(linkID uint, limit int) ([]models.LinkRoutingEvent, error)
+	QuarantineLink(linkID uint, reason string, at time.Time) (bool, error)
+	ReleaseQuarantine(link *models.Link) error
+	ListQuarantinedLinks() ([]models.Link, error)
 }		
 
 //GormLinkRepository est l'implémentation de LinkRepository utilisant GORM.
@@ -45,8 +59,50 @@ func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link,
 	return &link, nil
 }
 
+// GetLinkByShortCodeUnscoped récupère un lien par son shortCode, y compris s'il a été supprimé logiquement.
+// Elle sert à garantir l'unicité des codes, à restaurer un lien et à consulter l'historique de ses clics.
+func (r *GormLinkRepository) GetLinkByShortCodeUnscoped(shortCode string) (*models.Link, error) {
+	var link models.Link
+	if err := r.db.Unscoped().Where("Shortcode = ?", shortCode).First(&link).Error; err != nil {
+		return nil, fmt.Errorf("failed to get link by short code %s: %w", shortCode, err)
+	}
+	return &link, nil
+}
+
+// UpdateLink enregistre les modifications apportées à un lien par LinkService.UpdateLink.
+// Seules les colonnes modifiables par cette opération sont écrites : le budget de clics (used_clicks)
+// est incrémenté par ConsumeClick, le routage par SetRouting et la quarantaine par QuarantineLink
+// et ReleaseQuarantine. Une mise à jour concurrente d'une redirection ou du moniteur ne peut donc pas les annuler.
+func (r *GormLinkRepository) UpdateLink(link *models.Link) error {
+	err := r.db.Model(link).
+		Select("long_url", "disabled", "fallback_url", "owner_id", "quarantine_override", "updated_at").
+		Updates(link).Error
+	if err != nil {
+		return fmt.Errorf("failed to update link %s: %w", link.Shortcode, err)
+	}
+	return nil
+}
+
+// DeleteLink supprime logiquement un lien en renseignant sa colonne deleted_at.
+// Les clics associés ne sont pas touchés.
+func (r *GormLinkRepository) DeleteLink(link *models.Link) error {
+	if err := r.db.Delete(link).Error; err != nil {
+		return fmt.Errorf("failed to delete link %s: %w", link.Shortcode, err)
+	}
+	return nil
+}
+
+// RestoreLink annule la suppression logique d'un lien.
+func (r *GormLinkRepository) RestoreLink(link *models.Link) error {
+	if err := r.db.Unscoped().Model(link).Update("deleted_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to restore link %s: %w", link.Shortcode, err)
+	}
+	link.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
 // GetAllLinks récupère tous les liens de la base de données.
-// Cette méthode est utilisée par le moniteur d'URLs.
+// Cette méthode est utilisée par l'analyse des listes de blocage, qui examine aussi les liens désactivés.
 func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	var links []models.Link
 	if err := r.db.Model(&models.Link{}).Find(&links).Error; err != nil {
@@ -55,13 +111,232 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
-func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
+// GetMonitoredLinks récupère les liens dont le moniteur d'URLs vérifie la destination.
+// Les liens désactivés ou en quarantaine ne redirigent personne : les contacter ne sert à rien
+// et, pour une destination bloquée, ferait interroger un domaine malveillant à intervalle régulier.
+func (r *GormLinkRepository) GetMonitoredLinks() ([]models.Link, error) {
+	var links []models.Link
+	err := r.db.Model(&models.Link{}).
+		Where("disabled = ? AND quarantined = ?", false, false).
+		Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monitored links: %w", err)
+	}
+	return links, nil
+}
+
+// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné, agrégats de rétention compris.
+// Les clics de robots ne sont comptés que si includeBots vaut true.
+func (r *GormLinkRepository) CountClicksByLinkID(linkID uint, includeBots bool) (int, error) {
 	var count int64 // GORM retourne un int64 pour les comptes
-	
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+
+	tx := r.db.Model(&models.Click{}).Where("link_id = ?", linkID)
+	if !includeBots {
+		tx = tx.Where("is_bot = ?", false)
+	}
+	if err := tx.Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count clicks for link ID %d: %w", linkID, err)
 	}
 
-	return int(count), nil
+	// Clics plus anciens que la durée de rétention, conservés sous forme d'agrégats journaliers
+	rolledUpExpr := "clicks"
+	if includeBots {
+		rolledUpExpr = "clicks + bot_clicks"
+	}
+	var rolledUp int64
+	err := r.db.Model(&models.ClickRollup{}).
+		Select("COALESCE(SUM("+rolledUpExpr+"), 0)").
+		Where("link_id = ?", linkID).
+		Scan(&rolledUp).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rolled up clicks for link ID %d: %w", linkID, err)
+	}
+
+	return int(count + rolledUp), nil
+}
+
+// ConsumeClick décrémente de façon atomique le budget de redirections d'un lien.
+// L'incrément et la vérification du plafond se font dans une seule requête UPDATE,
+// ce qui garantit qu'aucune redirection concurrente ne peut dépasser MaxClicks.
+// Il retourne false si le budget est déjà épuisé.
+func (r *GormLinkRepository) ConsumeClick(linkID uint) (bool, error) {
+	result := r.db.Model(&models.Link{}).
+		Where("id = ? AND used_clicks < max_clicks", linkID).
+		UpdateColumn("used_clicks", gorm.Expr("used_clicks + 1"))
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to consume click for link ID %d: %w", linkID, result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetRouting bascule le routage d'un lien vers event.Routing et enregistre la bascule dans le journal
+// des événements, dans une même transaction. Il retourne false, sans rien enregistrer,
+// si le lien était déjà dans ce routage.
+func (r *GormLinkRepository) SetRouting(linkID uint, event *models.LinkRoutingEvent) (bool, error) {
+	failedOver := event.Routing == models.RoutingFallback
+	var failedOverAt *time.Time
+	if failedOver {
+		failedOverAt = &event.OccurredAt
+	}
+
+	switched := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Link{}).
+			Where("id = ? AND failed_over = ?", linkID, !failedOver).
+			UpdateColumns(map[string]interface{}{"failed_over": failedOver, "failed_over_at": failedOverAt})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		event.LinkID = linkID
+		switched = true
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to switch routing of link ID %d to %s: %w", linkID, event.Routing, err)
+	}
+	return switched, nil
+}
+
+// GetRoutingEvents retourne les limit dernières bascules de routage d'un lien, de la plus récente à la plus ancienne.
+func (r *GormLinkRepository) GetRoutingEvents(linkID uint, limit int) ([]models.LinkRoutingEvent, error) {
+	var events []models.LinkRoutingEvent
+	err := r.db.Where("link_id = ?", linkID).
+		Order("occurred_at DESC").Order("id DESC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routing events for link ID %d: %w", linkID, err)
+	}
+	return events, nil
+}
+
+// QuarantineLink met un lien en quarantaine, sauf s'il l'est déjà ou qu'un administrateur l'a libéré.
+// Il retourne true si le lien vient d'être mis en quarantaine.
+func (r *GormLinkRepository) QuarantineLink(linkID uint, reason string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.Link{}).
+		Where("id = ? AND quarantined = ? AND quarantine_override = ?", linkID, false, false).
+		UpdateColumns(map[string]interface{}{"quarantined": true, "quarantined_at": at, "quarantine_reason": reason})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to quarantine link ID %d: %w", linkID, result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseQuarantine sort un lien de quarantaine et le protège des analyses suivantes (quarantine_override).
+func (r *GormLinkRepository) ReleaseQuarantine(link *models.Link) error {
+	err := r.db.Unscoped().Model(link).
+		UpdateColumns(map[string]interface{}{"quarantined": false, "quarantine_override": true}).Error
+	if err != nil {
+		return fmt.Errorf("failed to release link %s from quarantine: %w", link.Shortcode, err)
+	}
+	return nil
+}
+
+// ListQuarantinedLinks retourne les liens en quarantaine (supprimés logiquement compris), les plus récents d'abord.
+func (r *GormLinkRepository) ListQuarantinedLinks() ([]models.Link, error) {
+	var links []models.Link
+	err := r.db.Unscoped().Where("quarantined = ?", true).
+		Order("quarantined_at DESC").
+		Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined links: %w", err)
+	}
+	return links, nil
+}
+
+// Critères de tri acceptés par ListLinks.
+const (
+	LinkSortCreatedAt = "created_at"
+	LinkSortClicks    = "clicks"
+)
+
+// LinkListQuery décrit une page de liens à récupérer : tri, filtres et position du curseur.
+type LinkListQuery struct {
+	Limit          int        // Nombre maximum de liens à retourner
+	SortBy         string     // LinkSortCreatedAt ou LinkSortClicks
+	Descending     bool       // Ordre décroissant si true
+	DomainContains string     // Filtre optionnel sur le domaine de destination (sous-chaîne, insensible à la casse)
+	CreatedAfter   *time.Time // Filtre optionnel sur la date de création
+	Owner          *LinkOwner // Filtre optionnel sur le propriétaire
+	After          *LinkCursor
+}
+
+// LinkOwner restreint un listing aux liens d'un utilisateur ; UserID nil désigne les liens sans propriétaire.
+type LinkOwner struct {
+	UserID *uint
+}
+
+// LinkCursor identifie le dernier lien de la page précédente (pagination par curseur / keyset).
+// Seule la valeur correspondant au critère de tri est utilisée, l'ID sert à départager les égalités.
+// La date de création est exprimée en secondes Unix pour ne pas dépendre du fuseau horaire.
+type LinkCursor struct {
+	CreatedAtUnix int64 `json:"created_at,omitempty"`
+	ClickCount    int   `json:"clicks,omitempty"`
+	ID            uint  `json:"id"`
+}
+
+// destinationHostExpr extrait (approximativement) la partie hôte de long_url en SQL :
+// ce qui suit "://" jusqu'au premier "/".
+const destinationHostExpr = "substr(substr(links.long_url, instr(links.long_url, '://') + 3), 1, " +
+	"instr(substr(links.long_url, instr(links.long_url, '://') + 3) || '/', '/') - 1)"
+
+// createdAtUnixExpr convertit created_at en secondes Unix en SQL. Les dates sont stockées
+// sous forme de texte avec le décalage horaire du moment de l'écriture : les comparer comme
+// chaînes mélangerait les fuseaux (changement d'heure, TZ du serveur modifiée).
+const createdAtUnixExpr = "CAST(strftime('%s', links.created_at) AS INTEGER)"
+
+// ListLinks retourne une page de liens non supprimés, triée et filtrée selon la requête.
+// La pagination se fait par curseur (keyset) : seules les lignes situées après query.After
+// sont lues, ce qui évite de charger toute la table en mémoire comme GetAllLinks.
+// Le tri par clics utilise le compteur click_count (indexé), sans agréger la table des clics.
+func (r *GormLinkRepository) ListLinks(query LinkListQuery) ([]models.Link, error) {
+	sortExpr := createdAtUnixExpr
+	if query.SortBy == LinkSortClicks {
+		sortExpr = "links.click_count"
+	}
+	direction, comparator := "ASC", ">"
+	if query.Descending {
+		direction, comparator = "DESC", "<"
+	}
+
+	tx := r.db.Model(&models.Link{})
+
+	if query.DomainContains != "" {
+		tx = tx.Where(destinationHostExpr+" LIKE ? ESCAPE '\\'", "%"+escapeLike(query.DomainContains)+"%")
+	}
+	if query.CreatedAfter != nil {
+		tx = tx.Where(createdAtUnixExpr+" > ?", query.CreatedAfter.Unix())
+	}
+	if query.Owner != nil {
+		if query.Owner.UserID == nil {
+			tx = tx.Where("links.owner_id IS NULL")
+		} else {
+			tx = tx.Where("links.owner_id = ?", *query.Owner.UserID)
+		}
+	}
+	if query.After != nil {
+		var sortValue interface{} = query.After.CreatedAtUnix
+		if query.SortBy == LinkSortClicks {
+			sortValue = query.After.ClickCount
+		}
+		tx = tx.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND links.id %s ?)", sortExpr, comparator, sortExpr, comparator),
+			sortValue, sortValue, query.After.ID,
+		)
+	}
+
+	var links []models.Link
+	err := tx.Order(sortExpr + " " + direction).
+		Order("links.id " + direction).
+		Limit(query.Limit).
+		Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	return links, nil
+}
+
+// escapeLike échappe les caractères spéciaux d'un motif LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
 }
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+)
+
+func TestUpdateLinkKeepsConcurrentClickBudget(t *testing.T) {
+	repo := NewLinkRepository(repositorytest.OpenDatabase(t))
+	link := &models.Link{Shortcode: "budget", LongURL: "https://example.com/a", MaxClicks: 5, FallbackURL: "https://example.com/b", Disabled: true}
+	if err := repo.CreateLink(link); err != nil {
+		t.Fatal(err)
+	}
+
+	// Un PATCH lit le lien, puis deux redirections consomment le budget avant son écriture.
+	stale, err := repo.GetLinkByShortCode("budget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if ok, err := repo.ConsumeClick(link.ID); err != nil || !ok {
+			t.Fatalf("ConsumeClick() = %v, %v", ok, err)
+		}
+	}
+
+	ownerID := uint(42)
+	stale.LongURL = "https://example.com/new"
+	stale.Disabled = false // Valeurs nulles : elles doivent aussi être écrites
+	stale.FallbackURL = ""
+	stale.OwnerID = &ownerID
+	if err := repo.UpdateLink(stale); err != nil {
+		t.Fatalf("UpdateLink() error = %v", err)
+	}
+
+	got, err := repo.GetLinkByShortCode("budget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UsedClicks != 2 {
+		t.Errorf("UsedClicks = %d after UpdateLink, want 2 (concurrent clicks were rolled back)", got.UsedClicks)
+	}
+	if got.LongURL != "https://example.com/new" || got.Disabled || got.FallbackURL != "" || got.OwnerID == nil || *got.OwnerID != ownerID {
+		t.Errorf("UpdateLink() saved %+v", got)
+	}
+}
+
+func TestCreateClicksMaintainsClickCount(t *testing.T) {
+	db := repositorytest.OpenDatabase(t)
+	linkRepo := NewLinkRepository(db)
+	clickRepo := NewClickRepository(db)
+
+	links := []*models.Link{
+		{Shortcode: "first", LongURL: "https://example.com/1"},
+		{Shortcode: "second", LongURL: "https://example.com/2"},
+		{Shortcode: "third", LongURL: "https://example.com/3"},
+	}
+	for _, link := range links {
+		if err := linkRepo.CreateLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Le deuxième lien est supprimé pendant que ses clics attendent dans la file des workers.
+	if err := linkRepo.DeleteLink(links[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	err := clickRepo.CreateClicks([]models.Click{
+		{LinkID: links[0].ID, Timestamp: now},
+		{LinkID: links[1].ID, Timestamp: now},
+		{LinkID: links[1].ID, Timestamp: now},
+		{LinkID: links[1].ID, Timestamp: now, IsBot: true}, // Les robots ne sont pas comptés
+		{LinkID: links[2].ID, Timestamp: now, IsBot: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clickRepo.CreateClick(&models.Click{LinkID: links[0].ID, Timestamp: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	for shortCode, want := range map[string]int{"first": 2, "second": 2, "third": 0} {
+		link, err := linkRepo.GetLinkByShortCodeUnscoped(shortCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.ClickCount != want {
+			t.Errorf("%s: ClickCount = %d, want %d", shortCode, link.ClickCount, want)
+		}
+	}
+}
+
+func TestListLinksSortsByClickCount(t *testing.T) {
+	db := repositorytest.OpenDatabase(t)
+	repo := NewLinkRepository(db)
+
+	// Les compteurs 3, 1, 3 et 0 : les égalités sont départagées par l'ID.
+	for i, clicks := range []int{3, 1, 3, 0} {
+		link := &models.Link{Shortcode: fmt.Sprintf("link%d", i), LongURL: "https://example.com", ClickCount: clicks}
+		if err := repo.CreateLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	query := LinkListQuery{Limit: 3, SortBy: LinkSortClicks, Descending: true}
+	for {
+		page, err := repo.ListLinks(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, link := range page {
+			got = append(got, fmt.Sprintf("%s=%d", link.Shortcode, link.ClickCount))
+		}
+		if len(page) < query.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		query.After = &LinkCursor{ClickCount: last.ClickCount, ID: last.ID}
+	}
+
+	want := []string{"link2=3", "link0=3", "link1=1", "link3=0"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListLinks(sort=clicks, desc) = %v, want %v", got, want)
+	}
+}
+
+func TestListLinksOrdersCreatedAtAcrossTimeZones(t *testing.T) {
+	repo := NewLinkRepository(repositorytest.OpenDatabase(t))
+
+	// Instants croissants écrits avec des décalages différents : comparés comme chaînes,
+	// "06:00-05:00" < "10:30+00:00" < "12:00+02:00" inverserait l'ordre réel.
+	base := time.Date(2025, 3, 30, 10, 0, 0, 0, time.UTC)
+	zones := []*time.Location{time.FixedZone("CEST", 2*3600), time.UTC, time.FixedZone("EST", -5*3600)}
+	for i, zone := range zones {
+		createdAt := base.Add(time.Duration(i) * 30 * time.Minute).In(zone)
+		link := &models.Link{Shortcode: fmt.Sprintf("link%d", i), LongURL: "https://example.com", CreatedAt: createdAt}
+		if err := repo.CreateLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	query := LinkListQuery{Limit: 1, SortBy: LinkSortCreatedAt}
+	for {
+		page, err := repo.ListLinks(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, link := range page {
+			got = append(got, link.Shortcode)
+		}
+		if len(page) < query.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		query.After = &LinkCursor{CreatedAtUnix: last.CreatedAt.Unix(), ID: last.ID}
+	}
+	if want := []string{"link0", "link1", "link2"}; !slices.Equal(got, want) {
+		t.Errorf("ListLinks(sort=created_at, asc) = %v, want %v", got, want)
+	}
+
+	// Le filtre de date compare lui aussi des instants, quel que soit le fuseau de la borne.
+	after := base.Add(15 * time.Minute).In(time.FixedZone("JST", 9*3600))
+	page, err := repo.ListLinks(LinkListQuery{Limit: 10, SortBy: LinkSortCreatedAt, CreatedAfter: &after})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for _, link := range page {
+		got = append(got, link.Shortcode)
+	}
+	if want := []string{"link1", "link2"}; !slices.Equal(got, want) {
+		t.Errorf("ListLinks(created_after=%s) = %v, want %v", after.Format(time.RFC3339), got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// backfillClickCountsSQL calcule le compteur de clics de chaque lien à partir des clics humains
+// et des agrégats journaliers produits par la politique de rétention.
+const backfillClickCountsSQL = `UPDATE links SET click_count =
+	(SELECT COUNT(*) FROM clicks WHERE clicks.link_id = links.id AND clicks.is_bot = false) +
+	(SELECT COALESCE(SUM(clicks), 0) FROM click_rollups WHERE click_rollups.link_id = links.id)`
+
+// Migrate applique les migrations automatiques de GORM à tous les modèles, dans une transaction.
+// Lorsqu'elle ajoute la colonne links.click_count à une base existante, elle l'initialise
+// à partir des clics déjà enregistrés ; les lots de clics suivants la maintiennent à jour.
+func Migrate(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		backfill := tx.Migrator().HasTable(&models.Link{}) && !tx.Migrator().HasColumn(&models.Link{}, "ClickCount")
+		if err := tx.AutoMigrate(models.All()...); err != nil {
+			return err
+		}
+		if !backfill {
+			return nil
+		}
+		if err := tx.Exec(backfillClickCountsSQL).Error; err != nil {
+			return fmt.Errorf("failed to backfill link click counts: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+)
+
+func TestMigrateBackfillsClickCounts(t *testing.T) {
+	db := repositorytest.OpenDatabase(t)
+
+	// Base antérieure au compteur : des clics bruts et des agrégats, sans colonne click_count.
+	link := &models.Link{Shortcode: "legacy", LongURL: "https://example.com"}
+	if err := db.Create(link).Error; err != nil {
+		t.Fatal(err)
+	}
+	clicks := []models.Click{
+		{LinkID: link.ID, Timestamp: time.Now()},
+		{LinkID: link.ID, Timestamp: time.Now()},
+		{LinkID: link.ID, Timestamp: time.Now(), IsBot: true},
+	}
+	if err := db.Create(&clicks).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.ClickRollup{LinkID: link.ID, Day: "2025-01-01", Clicks: 5, BotClicks: 4}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrator().DropColumn(&models.Link{}, "ClickCount"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	var got models.Link
+	if err := db.First(&got, link.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.ClickCount != 7 {
+		t.Errorf("ClickCount after backfill = %d, want 7", got.ClickCount)
+	}
+
+	// Une fois la colonne présente, Migrate ne recalcule plus le compteur.
+	if err := db.Model(&got).UpdateColumn("click_count", 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&got, link.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.ClickCount != 1 {
+		t.Errorf("ClickCount after second Migrate = %d, want 1", got.ClickCount)
+	}
+}
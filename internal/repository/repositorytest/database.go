@@ -0,0 +1,34 @@
+// Package repositorytest fournit aux tests des autres paquets une base SQLite prête à l'emploi.
+package repositorytest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// OpenDatabase ouvre une base SQLite migrée dans un fichier temporaire (et non en mémoire,
+// pour mesurer le coût réel des transactions dans les benchmarks), fermée à la fin du test.
+// Les requêtes ne sont pas journalisées.
+func OpenDatabase(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tb.TempDir(), "test.db")), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		tb.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(models.All()...); err != nil {
+		tb.Fatalf("failed to migrate database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { sqlDB.Close() })
+	return db
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// UserRepository définit les méthodes d'accès aux utilisateurs.
+type UserRepository interface {
+	CreateUser(user *models.User) error
+	GetUserByName(name string) (*models.User, error)
+	ListUsers() ([]models.User, error)
+}
+
+// GormUserRepository est l'implémentation de UserRepository utilisant GORM.
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository crée et retourne une nouvelle instance de GormUserRepository.
+func NewUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+// CreateUser insère un nouvel utilisateur.
+func (r *GormUserRepository) CreateUser(user *models.User) error {
+	if err := r.db.Create(user).Error; err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// GetUserByName récupère un utilisateur par son nom.
+// Il renvoie gorm.ErrRecordNotFound si aucun utilisateur ne porte ce nom.
+func (r *GormUserRepository) GetUserByName(name string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("name = ?", name).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", name, err)
+	}
+	return &user, nil
+}
+
+// ListUsers retourne tous les utilisateurs, du plus ancien au plus récent.
+func (r *GormUserRepository) ListUsers() ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Order("id").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
@@ -0,0 +1,86 @@
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// DefaultBatchSize est le nombre de clics agrégés puis supprimés par transaction si aucune taille n'est configurée.
+const DefaultBatchSize = 1000
+
+// purgeInterval est l'intervalle entre deux passes de purge.
+const purgeInterval = time.Hour
+
+// batchPause laisse la base disponible pour les écritures des workers entre deux lots.
+const batchPause = 100 * time.Millisecond
+
+// Purger applique périodiquement la politique de rétention des clics :
+// les clics plus anciens que la durée de rétention sont agrégés par lien et par jour,
+// puis supprimés de la table 'clicks' par lots de taille bornée.
+type Purger struct {
+	clickRepo repository.ClickRepository
+	retention time.Duration
+	batchSize int
+}
+
+// NewPurger crée un Purger conservant les clics bruts pendant retentionDays jours.
+func NewPurger(clickRepo repository.ClickRepository, retentionDays, batchSize int) *Purger {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Purger{
+		clickRepo: clickRepo,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		batchSize: batchSize,
+	}
+}
+
+// Start lance la boucle de purge périodique jusqu'à l'annulation de ctx.
+// Une première passe est effectuée immédiatement.
+func (p *Purger) Start(ctx context.Context) {
+	logger().Info("Starting click retention purge", "retention", p.retention.String(), "batch_size", p.batchSize)
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	p.purge(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.purge(ctx)
+		}
+	}
+}
+
+// purge agrège et supprime, lot par lot, tous les clics antérieurs à la date limite de rétention.
+// L'annulation de ctx interrompt la purge entre deux lots.
+func (p *Purger) purge(ctx context.Context) {
+	cutoff := time.Now().Add(-p.retention)
+	total := 0
+
+	for {
+		processed, err := p.clickRepo.RollupClicksBefore(cutoff, p.batchSize)
+		if err != nil {
+			logger().Error("Failed to roll up clicks", "error", err)
+			break
+		}
+		total += processed
+		if processed < p.batchSize || ctx.Err() != nil {
+			break
+		}
+		time.Sleep(batchPause)
+	}
+
+	if total > 0 {
+		logger().Info("Old clicks rolled up and deleted", "clicks", total, "cutoff", cutoff.Format(time.RFC3339))
+	}
+}
+
+// logger retourne le logger de la purge, dérivé du logger par défaut au moment de l'appel.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "retention")
+}
@@ -0,0 +1,126 @@
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+)
+
+func TestPurgeRollsUpAndDeletesOldClicks(t *testing.T) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	db := repositorytest.OpenDatabase(t)
+	clickRepo := repository.NewClickRepository(db)
+
+	links := []models.Link{
+		{Shortcode: "first", LongURL: "https://example.com/a"},
+		{Shortcode: "second", LongURL: "https://example.com/b"},
+	}
+	if err := db.Create(&links).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	oldDay := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.UTC).AddDate(0, 0, -100)
+	older := oldDay.AddDate(0, 0, -1)
+	clicks := []models.Click{
+		{LinkID: links[0].ID, Timestamp: oldDay},
+		{LinkID: links[0].ID, Timestamp: oldDay.Add(time.Hour)},
+		{LinkID: links[0].ID, Timestamp: oldDay.Add(2 * time.Hour), IsBot: true},
+		{LinkID: links[0].ID, Timestamp: older},
+		{LinkID: links[1].ID, Timestamp: oldDay},
+		{LinkID: links[0].ID, Timestamp: now.Add(-time.Hour)}, // Dans la période de rétention : conservé tel quel
+	}
+	if err := db.Create(&clicks).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Agrégat laissé par une passe précédente pour la même journée : il doit être complété, pas remplacé.
+	if err := db.Create(&models.ClickRollup{LinkID: links[0].ID, Day: oldDay.Format("2006-01-02"), Clicks: 10, BotClicks: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	totalBefore, err := clickRepo.CountClicksByLinkID(links[0].ID, repository.ClickFilter{IncludeBots: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Des lots de 2 clics obligent la purge à enchaîner plusieurs transactions.
+	NewPurger(clickRepo, 30, 2).purge(context.Background())
+
+	var remaining []models.Click
+	if err := db.Find(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || !remaining[0].Timestamp.After(now.Add(-2*time.Hour)) {
+		t.Fatalf("remaining clicks = %+v, want only the recent one", remaining)
+	}
+
+	var rollups []models.ClickRollup
+	if err := db.Order("link_id, day").Find(&rollups).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ClickRollup{
+		{LinkID: links[0].ID, Day: older.Format("2006-01-02"), Clicks: 1, BotClicks: 0},
+		{LinkID: links[0].ID, Day: oldDay.Format("2006-01-02"), Clicks: 12, BotClicks: 2},
+		{LinkID: links[1].ID, Day: oldDay.Format("2006-01-02"), Clicks: 1, BotClicks: 0},
+	}
+	if len(rollups) != len(want) {
+		t.Fatalf("rollups = %+v, want %+v", rollups, want)
+	}
+	for i := range want {
+		got := rollups[i]
+		got.ID = 0
+		if got != want[i] {
+			t.Errorf("rollup %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// Les totaux d'un lien incluent les agrégats : la purge ne les modifie pas.
+	totalAfter, err := clickRepo.CountClicksByLinkID(links[0].ID, repository.ClickFilter{IncludeBots: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totalAfter != totalBefore {
+		t.Errorf("total clicks after purge = %d, want %d", totalAfter, totalBefore)
+	}
+}
+
+func TestPurgeStopsBetweenBatchesWhenCancelled(t *testing.T) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	db := repositorytest.OpenDatabase(t)
+	link := models.Link{Shortcode: "busy", LongURL: "https://example.com"}
+	if err := db.Create(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().AddDate(0, 0, -60)
+	clicks := make([]models.Click, 5)
+	for i := range clicks {
+		clicks[i] = models.Click{LinkID: link.ID, Timestamp: old}
+	}
+	if err := db.Create(&clicks).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewPurger(repository.NewClickRepository(db), 30, 2).purge(ctx)
+
+	// Un seul lot est traité : les clics restants le seront à la passe suivante.
+	var count int64
+	if err := db.Model(&models.Click{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("remaining clicks = %d, want 3", count)
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+// APIKeyTokenPrefix préfixe toutes les clés d'API, pour les reconnaître (dans un gestionnaire de secrets, un dépôt...).
+const APIKeyTokenPrefix = "usk_"
+
+// apiKeyPrefixLength est le nombre de caractères de la clé conservés en clair pour l'identifier.
+const apiKeyPrefixLength = 12
+
+// apiKeyTouchInterval limite la fréquence d'écriture de la date de dernière utilisation d'une clé.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService gère la création, la révocation et la vérification des clés d'API.
+type APIKeyService struct {
+	keyRepo repository.APIKeyRepository
+	now     func() time.Time
+}
+
+// NewAPIKeyService crée et retourne une nouvelle instance de APIKeyService.
+func NewAPIKeyService(keyRepo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{keyRepo: keyRepo, now: time.Now}
+}
+
+// CreateKey génère une nouvelle clé d'API portant les scopes donnés, agissant au nom de l'utilisateur userID
+// (obligatoire sauf pour une clé portant le scope admin).
+// La clé en clair est retournée une seule fois : seule son empreinte est enregistrée.
+func (s *APIKeyService) CreateKey(name string, userID *uint, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidAPIKeyOptions)
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	// Les liens sans propriétaire sont réservés aux administrateurs : une clé sans utilisateur n'aurait accès à rien.
+	if userID == nil && !slices.Contains(scopes, models.ScopeAdmin) {
+		return nil, "", fmt.Errorf("%w: a user is required for keys without the %s scope", ErrInvalidAPIKeyOptions, models.ScopeAdmin)
+	}
+	if expiresAt != nil && !expiresAt.After(s.now()) {
+		return nil, "", fmt.Errorf("%w: expiration date must be in the future", ErrInvalidAPIKeyOptions)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	token := APIKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    token[:apiKeyPrefixLength],
+		Hash:      hashAPIKey(token),
+		Scopes:    strings.Join(scopes, ","),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.keyRepo.CreateKey(key); err != nil {
+		return nil, "", err
+	}
+	return key, token, nil
+}
+
+// ListKeys retourne toutes les clés d'API, révoquées comprises.
+func (s *APIKeyService) ListKeys() ([]models.APIKey, error) {
+	return s.keyRepo.ListKeys()
+}
+
+// RevokeKey révoque la clé d'API d'identifiant id. Elle est refusée dès la requête suivante.
+// Il renvoie gorm.ErrRecordNotFound si la clé n'existe pas et ErrAPIKeyRevoked si elle est déjà révoquée.
+func (s *APIKeyService) RevokeKey(id uint) (*models.APIKey, error) {
+	key, err := s.keyRepo.GetKeyByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if err := s.keyRepo.RevokeKey(key, s.now()); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Authenticate retrouve la clé d'API correspondant à token et vérifie qu'elle est encore valide.
+// Il renvoie ErrInvalidAPIKey si la clé est inconnue, révoquée, expirée, ou sans utilisateur ni scope admin.
+func (s *APIKeyService) Authenticate(token string) (*models.APIKey, error) {
+	if !strings.HasPrefix(token, APIKeyTokenPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.keyRepo.GetKeyByHash(hashAPIKey(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: key %s has been revoked", ErrInvalidAPIKey, key.Prefix)
+	}
+	if key.IsExpired(now) {
+		return nil, fmt.Errorf("%w: key %s has expired", ErrInvalidAPIKey, key.Prefix)
+	}
+	// Clé créée avant l'obligation de rattachement : elle donnait accès aux liens sans propriétaire.
+	if key.UserID == nil && !key.HasScope(models.ScopeAdmin) {
+		return nil, fmt.Errorf("%w: key %s is not bound to a user", ErrInvalidAPIKey, key.Prefix)
+	}
+
+	// La date de dernière utilisation est indicative : on évite une écriture par requête.
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.keyRepo.TouchKey(key.ID, now); err != nil {
+			slog.Default().With("component", "api_keys").Warn("Failed to record API key use", "key_id", key.ID, "error", err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// normalizeScopes vérifie les scopes demandés et retire les doublons, dans l'ordre de models.AllScopes.
+func normalizeScopes(scopes []string) ([]string, error) {
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if !slices.Contains(models.AllScopes, scope) {
+			return nil, fmt.Errorf("%w: %q (expected one of %s)", ErrInvalidAPIKeyOptions, scope, strings.Join(models.AllScopes, ", "))
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyOptions)
+	}
+	slices.SortFunc(normalized, func(a, b string) int {
+		return slices.Index(models.AllScopes, a) - slices.Index(models.AllScopes, b)
+	})
+	return normalized, nil
+}
+
+// hashAPIKey calcule l'empreinte SHA-256 hexadécimale d'une clé d'API.
+// Les clés étant aléatoires et longues, un hachage lent (bcrypt...) n'apporte rien ici.
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
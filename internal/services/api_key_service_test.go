@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+)
+
+func TestAPIKeysRequireUserUnlessAdmin(t *testing.T) {
+	db := repositorytest.OpenDatabase(t)
+	keyRepo := repository.NewAPIKeyRepository(db)
+	service := NewAPIKeyService(keyRepo)
+	userID := uint(1)
+
+	if _, _, err := service.CreateKey("reader", nil, []string{models.ScopeLinksRead}, nil); !errors.Is(err, ErrInvalidAPIKeyOptions) {
+		t.Errorf("CreateKey() without user error = %v, want ErrInvalidAPIKeyOptions", err)
+	}
+	if _, _, err := service.CreateKey("reader", &userID, []string{models.ScopeLinksRead}, nil); err != nil {
+		t.Errorf("CreateKey() with user error = %v", err)
+	}
+	_, adminToken, err := service.CreateKey("ops", nil, []string{models.ScopeAdmin}, nil)
+	if err != nil {
+		t.Fatalf("CreateKey() admin without user error = %v", err)
+	}
+	if _, err := service.Authenticate(adminToken); err != nil {
+		t.Errorf("Authenticate() admin key error = %v", err)
+	}
+
+	// Une clé sans utilisateur créée avant la règle est refusée.
+	key, token, err := service.CreateKey("legacy", &userID, []string{models.ScopeLinksRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(key).Update("user_id", nil).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(token); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate() unbound key error = %v, want ErrInvalidAPIKey", err)
+	}
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/axellelanca/urlshortener/internal/analytics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
 )
@@ -11,16 +14,39 @@ import (
 // Elle est juste composer de clickRepo qui est de type ClickRepository
 type ClickService struct {
 	clickRepo repository.ClickRepository
+	retention time.Duration // Durée de conservation des clics bruts (0 = illimitée)
 }
 
 // NewClickService crée et retourne une nouvelle instance de ClickService.
 // C'est la fonction recommandée pour obtenir un service, assurant que toutes ses dépendances sont injectées.
-func NewClickService(clickRepo repository.ClickRepository) *ClickService {
+// retentionDays est la durée de conservation des clics bruts (analytics.retention_days, 0 = illimitée).
+func NewClickService(clickRepo repository.ClickRepository, retentionDays int) *ClickService {
 	return &ClickService{
 		clickRepo: clickRepo,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
 	}
 }
 
+// BreakdownWindow restreint la période d'un filtre à celle où les clics bruts sont encore conservés.
+// Au-delà de la durée de rétention, les clics ne subsistent qu'agrégés par jour, sans référent,
+// appareil ni pays : une répartition sur une période plus ancienne serait incomplète sans le dire.
+// truncated indique si le début de la période a été avancé à la limite de rétention.
+func (s *ClickService) BreakdownWindow(filter repository.ClickFilter) (repository.ClickFilter, bool) {
+	if s.retention <= 0 {
+		return filter, false
+	}
+	cutoff := time.Now().Add(-s.retention)
+	if !filter.From.Before(cutoff) {
+		return filter, false
+	}
+	filter.From = cutoff
+	if !filter.To.IsZero() && filter.To.Before(cutoff) {
+		// Période entièrement purgée : la répartition est vide.
+		filter.From = filter.To
+	}
+	return filter, true
+}
+
 // RecordClick enregistre un nouvel événement de clic dans la base de données.
 // Cette méthode est appelée par le worker asynchrone.
 func (s *ClickService) RecordClick(click *models.Click) error {
@@ -30,12 +56,150 @@ func (s *ClickService) RecordClick(click *models.Click) error {
 	return nil
 }
 
-// GetClicksCountByLinkID récupère le nombre total de clics pour un LinkID donné.
+// GetClicksCountByLinkID récupère le nombre total de clics pour un LinkID donné, sur la période du filtre.
 // Cette méthode pourrait être utilisée par le LinkService pour les statistiques, ou directement par l'API stats.
-func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
-	count, err := s.clickRepo.CountClicksByLinkID(linkID)
+func (s *ClickService) GetClicksCountByLinkID(linkID uint, filter repository.ClickFilter) (int, error) {
+	count, err := s.clickRepo.CountClicksByLinkID(linkID, filter)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get clicks count for link ID %d: %w", linkID, err)
 	}
 	return count, nil
 }
+
+// GetTopReferrers retourne les domaines référents les plus fréquents d'un lien sur une période,
+// limitée aux clics bruts conservés (voir BreakdownWindow).
+// Les accès sans référent sont libellés analytics.DirectReferrer.
+func (s *ClickService) GetTopReferrers(linkID uint, filter repository.ClickFilter, limit int) ([]repository.ReferrerCount, error) {
+	filter, _ = s.BreakdownWindow(filter)
+	referrers, err := s.clickRepo.TopReferrers(linkID, filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top referrers for link ID %d: %w", linkID, err)
+	}
+	for i := range referrers {
+		if referrers[i].Domain == "" {
+			referrers[i].Domain = analytics.DirectReferrer
+		}
+	}
+	return referrers, nil
+}
+
+// DeviceBreakdown est la répartition des clics d'un lien par navigateur, système et type d'appareil.
+type DeviceBreakdown struct {
+	Browsers    []repository.DimensionCount
+	OS          []repository.DimensionCount
+	DeviceTypes []repository.DimensionCount
+	BotClicks   int // Nombre de clics de robots sur la période, qu'ils soient inclus ou non dans les répartitions
+}
+
+// GetDeviceBreakdown calcule la répartition des clics d'un lien par navigateur, OS et type d'appareil,
+// sur la partie de la période où les clics bruts sont conservés (voir BreakdownWindow).
+func (s *ClickService) GetDeviceBreakdown(linkID uint, filter repository.ClickFilter) (*DeviceBreakdown, error) {
+	var err error
+	filter, _ = s.BreakdownWindow(filter)
+	breakdown := &DeviceBreakdown{}
+
+	if breakdown.Browsers, err = s.countByDimension(linkID, repository.DimensionBrowser, filter, analytics.Unknown); err != nil {
+		return nil, err
+	}
+	if breakdown.OS, err = s.countByDimension(linkID, repository.DimensionOS, filter, analytics.Unknown); err != nil {
+		return nil, err
+	}
+	if breakdown.DeviceTypes, err = s.countByDimension(linkID, repository.DimensionDeviceType, filter, analytics.DeviceUnknown); err != nil {
+		return nil, err
+	}
+	if breakdown.BotClicks, err = s.countBotClicks(linkID, filter); err != nil {
+		return nil, err
+	}
+	return breakdown, nil
+}
+
+// GeoBreakdown est la répartition géographique des clics d'un lien.
+// Regions et Cities ne sont renseignés que lorsque le filtre cible un pays.
+type GeoBreakdown struct {
+	Countries []repository.DimensionCount
+	Regions   []repository.DimensionCount
+	Cities    []repository.DimensionCount
+}
+
+// GetGeoBreakdown calcule la répartition des clics d'un lien par pays.
+// Si filter.Country est renseigné, le détail par région et par ville de ce pays est également calculé.
+// Comme les autres répartitions, elle ne couvre que les clics bruts conservés (voir BreakdownWindow).
+func (s *ClickService) GetGeoBreakdown(linkID uint, filter repository.ClickFilter) (*GeoBreakdown, error) {
+	var err error
+	filter, _ = s.BreakdownWindow(filter)
+	breakdown := &GeoBreakdown{}
+
+	if breakdown.Countries, err = s.countByDimension(linkID, repository.DimensionCountry, filter, analytics.UnknownLocation); err != nil {
+		return nil, err
+	}
+	if filter.Country == "" {
+		return breakdown, nil
+	}
+	if breakdown.Regions, err = s.countByDimension(linkID, repository.DimensionRegion, filter, analytics.UnknownLocation); err != nil {
+		return nil, err
+	}
+	if breakdown.Cities, err = s.countByDimension(linkID, repository.DimensionCity, filter, analytics.UnknownLocation); err != nil {
+		return nil, err
+	}
+	return breakdown, nil
+}
+
+// countByDimension répartit les clics d'un lien selon une dimension,
+// en regroupant les valeurs vides sous le libellé unknown.
+func (s *ClickService) countByDimension(linkID uint, dimension string, filter repository.ClickFilter, unknown string) ([]repository.DimensionCount, error) {
+	counts, err := s.clickRepo.CountClicksByDimension(linkID, dimension, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s breakdown for link ID %d: %w", dimension, linkID, err)
+	}
+	return labelUnknown(counts, unknown), nil
+}
+
+// labelUnknown renomme les valeurs vides (clics enregistrés avant l'enrichissement, ou non résolus)
+// avec le libellé inconnu et fusionne le résultat avec une éventuelle entrée existante de même libellé.
+func labelUnknown(counts []repository.DimensionCount, unknown string) []repository.DimensionCount {
+	empty := -1
+	for i, c := range counts {
+		if c.Value == "" {
+			empty = i
+		}
+	}
+	if empty < 0 {
+		return counts
+	}
+
+	extra := counts[empty].Clicks
+	merged := append(counts[:empty:empty], counts[empty+1:]...)
+	for i := range merged {
+		if merged[i].Value == unknown {
+			merged[i].Clicks += extra
+			return sortByClicks(merged)
+		}
+	}
+	return sortByClicks(append(merged, repository.DimensionCount{Value: unknown, Clicks: extra}))
+}
+
+// sortByClicks trie une répartition par nombre de clics décroissant, puis par libellé.
+func sortByClicks(counts []repository.DimensionCount) []repository.DimensionCount {
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Clicks != counts[j].Clicks {
+			return counts[i].Clicks > counts[j].Clicks
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	return counts
+}
+
+// countBotClicks compte les clics de robots d'un lien sur la période du filtre.
+func (s *ClickService) countBotClicks(linkID uint, filter repository.ClickFilter) (int, error) {
+	filter.IncludeBots = true
+	all, err := s.clickRepo.CountClicksByLinkID(linkID, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count bot clicks for link ID %d: %w", linkID, err)
+	}
+	filter.IncludeBots = false
+	humans, err := s.clickRepo.CountClicksByLinkID(linkID, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count bot clicks for link ID %d: %w", linkID, err)
+	}
+	return all - humans, nil
+}
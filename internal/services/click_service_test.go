@@ -0,0 +1,82 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+)
+
+func TestBreakdownsOnlyCoverRetainedClicks(t *testing.T) {
+	db := repositorytest.OpenDatabase(t)
+	link := models.Link{Shortcode: "retained", LongURL: "https://example.com"}
+	if err := db.Create(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Un clic ancien pas encore purgé et un clic récent : seul le second est dans la période de rétention.
+	now := time.Now()
+	clicks := []models.Click{
+		{LinkID: link.ID, Timestamp: now.AddDate(0, 0, -45).UTC(), ReferrerDomain: "old.example", Browser: "Firefox"},
+		{LinkID: link.ID, Timestamp: now.Add(-time.Hour).UTC(), ReferrerDomain: "new.example", Browser: "Chrome"},
+	}
+	if err := db.Create(&clicks).Error; err != nil {
+		t.Fatal(err)
+	}
+	clickRepo := repository.NewClickRepository(db)
+
+	tests := []struct {
+		name          string
+		retentionDays int
+		from          time.Time
+		wantTruncated bool
+		wantReferrers []string
+	}{
+		{"unlimited retention", 0, now.AddDate(0, 0, -60), false, []string{"new.example", "old.example"}},
+		{"window inside retention", 30, now.AddDate(0, 0, -7), false, []string{"new.example"}},
+		{"window before cutoff", 30, now.AddDate(0, 0, -60), true, []string{"new.example"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewClickService(clickRepo, tt.retentionDays)
+			filter := repository.ClickFilter{From: tt.from, To: now}
+
+			window, truncated := service.BreakdownWindow(filter)
+			if truncated != tt.wantTruncated {
+				t.Errorf("BreakdownWindow() truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+			if truncated && !window.From.After(tt.from) {
+				t.Errorf("BreakdownWindow() from = %s, want it moved past %s", window.From, tt.from)
+			}
+
+			referrers, err := service.GetTopReferrers(link.ID, filter, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range referrers {
+				got = append(got, r.Domain)
+			}
+			if !slices.Equal(got, tt.wantReferrers) {
+				t.Errorf("GetTopReferrers() = %v, want %v", got, tt.wantReferrers)
+			}
+
+			breakdown, err := service.GetDeviceBreakdown(link.ID, filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if browsers := len(breakdown.Browsers); browsers != len(tt.wantReferrers) {
+				t.Errorf("GetDeviceBreakdown() browsers = %v, want %d entries", breakdown.Browsers, len(tt.wantReferrers))
+			}
+		})
+	}
+
+	// Une période entièrement purgée donne une répartition vide plutôt qu'incomplète.
+	service := NewClickService(clickRepo, 30)
+	window, truncated := service.BreakdownWindow(repository.ClickFilter{From: now.AddDate(0, 0, -60), To: now.AddDate(0, 0, -40)})
+	if !truncated || !window.From.Equal(window.To) {
+		t.Errorf("BreakdownWindow(purged period) = [%s, %s) truncated=%v, want an empty window", window.From, window.To, truncated)
+	}
+}
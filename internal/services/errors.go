@@ -0,0 +1,46 @@
+package services
+
+import "errors"
+
+// Erreurs métiers retournées par les services.
+// Les handlers de l'API les testent avec errors.Is pour choisir le code HTTP adapté.
+var (
+	// ErrInvalidAlias est retournée lorsqu'un alias personnalisé ne respecte pas les règles de format.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrAliasTaken est retournée lorsqu'un alias personnalisé est déjà utilisé par un autre lien.
+	ErrAliasTaken = errors.New("alias already in use")
+	// ErrURLRejected est retournée lorsqu'une URL de destination enfreint la politique des URLs (urlpolicy).
+	ErrURLRejected = errors.New("destination url rejected")
+	// ErrURLBlocked est retournée lorsqu'une URL de destination figure dans une liste de blocage.
+	ErrURLBlocked = errors.New("destination url is blocklisted")
+	// ErrLinkQuarantined est retournée lorsqu'un lien est en quarantaine : il ne redirige plus.
+	ErrLinkQuarantined = errors.New("link is quarantined")
+	// ErrLinkNotQuarantined est retournée lorsqu'on tente de libérer un lien qui n'est pas en quarantaine.
+	ErrLinkNotQuarantined = errors.New("link is not quarantined")
+	// ErrAdminRequired est retournée lorsqu'une opération réservée aux administrateurs est demandée par un autre principal.
+	ErrAdminRequired = errors.New("administrator access required")
+	// ErrInvalidLinkOptions est retournée lorsque les options d'un lien (expiration, budget de clics) sont incohérentes.
+	ErrInvalidLinkOptions = errors.New("invalid link options")
+	// ErrLinkExpired est retournée lorsqu'un lien a dépassé sa date d'expiration.
+	ErrLinkExpired = errors.New("link has expired")
+	// ErrClickLimitReached est retournée lorsqu'un lien a épuisé son budget de clics.
+	ErrClickLimitReached = errors.New("link has reached its maximum number of clicks")
+	// ErrLinkDisabled est retournée lorsqu'un lien a été désactivé par son propriétaire.
+	ErrLinkDisabled = errors.New("link is disabled")
+	// ErrLinkNotDeleted est retournée lorsqu'on tente de restaurer un lien qui n'a pas été supprimé.
+	ErrLinkNotDeleted = errors.New("link is not deleted")
+	// ErrInvalidListParams est retournée lorsque les paramètres de listing (tri, limite, curseur) sont invalides.
+	ErrInvalidListParams = errors.New("invalid list parameters")
+	// ErrInvalidStatsParams est retournée lorsque les paramètres d'une requête de statistiques sont invalides.
+	ErrInvalidStatsParams = errors.New("invalid statistics parameters")
+	// ErrInvalidAPIKey est retournée lorsqu'une clé d'API est inconnue, révoquée, expirée ou rattachée à aucun utilisateur.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInvalidAPIKeyOptions est retournée lorsque les options d'une clé d'API (nom, scopes, expiration) sont invalides.
+	ErrInvalidAPIKeyOptions = errors.New("invalid api key options")
+	// ErrAPIKeyRevoked est retournée lorsqu'on tente de révoquer une clé déjà révoquée.
+	ErrAPIKeyRevoked = errors.New("api key is already revoked")
+	// ErrInvalidUser est retournée lorsqu'un nom d'utilisateur ne respecte pas les règles de format.
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrUserExists est retournée lorsqu'un nom d'utilisateur est déjà pris.
+	ErrUserExists = errors.New("user already exists")
+)
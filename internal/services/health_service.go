@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// États de santé de l'URL longue d'un lien, d'après sa dernière vérification.
+const (
+	HealthUp      = models.LinkStateUp
+	HealthDown    = models.LinkStateDown
+	HealthChanged = models.LinkStateChanged // Accessible, mais redirigée vers un autre hôte
+	HealthUnknown = "unknown"               // Jamais vérifiée
+)
+
+// HealthService fournit l'état de santé des URLs longues à partir de l'historique du moniteur.
+type HealthService struct {
+	checkRepo repository.LinkCheckRepository
+}
+
+// NewHealthService crée et retourne une nouvelle instance de HealthService.
+func NewHealthService(checkRepo repository.LinkCheckRepository) *HealthService {
+	return &HealthService{checkRepo: checkRepo}
+}
+
+// LinkHealth est l'état de santé de l'URL longue d'un lien.
+// UptimePercent est nil lorsqu'aucune vérification n'a eu lieu sur la période.
+type LinkHealth struct {
+	State         string
+	LastCheck     *models.LinkCheck
+	Checks        int      // Nombre de vérifications sur la période
+	UptimePercent *float64 // Part des vérifications de la période où l'URL était accessible
+	Recent        []models.LinkCheck
+}
+
+// GetLinkHealth calcule l'état actuel d'un lien, sa disponibilité entre from et to
+// et retourne ses limit dernières vérifications (de la plus récente à la plus ancienne).
+func (s *HealthService) GetLinkHealth(linkID uint, from, to time.Time, limit int) (*LinkHealth, error) {
+	if limit < 1 {
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalidStatsParams)
+	}
+
+	recent, err := s.checkRepo.LatestChecks(linkID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health history for link ID %d: %w", linkID, err)
+	}
+	counts, err := s.checkRepo.CountChecks(linkID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uptime for link ID %d: %w", linkID, err)
+	}
+
+	health := &LinkHealth{State: HealthUnknown, Checks: counts.Total, Recent: recent}
+	if len(recent) > 0 {
+		health.LastCheck = &recent[0]
+		health.State = recent[0].State()
+	}
+	if counts.Total > 0 {
+		uptime := float64(counts.Up) * 100 / float64(counts.Total)
+		health.UptimePercent = &uptime
+	}
+	return health, nil
+}
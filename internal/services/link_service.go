@@ -2,35 +2,103 @@ package services
 
 import (
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
 
+	"github.com/axellelanca/urlshortener/internal/blocklist"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 )
 
 // Définition du jeu de caractères pour la génération des codes courts.
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// Contraintes sur les alias personnalisés (vanity URLs).
+const (
+	MinAliasLength = 3
+	MaxAliasLength = 32
+)
+
+// aliasPattern n'accepte que des lettres, chiffres, tirets et underscores,
+// et impose de commencer par une lettre ou un chiffre.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// reservedAliases liste les mots qui entreraient en conflit avec les routes du serveur.
+// La comparaison se fait sans tenir compte de la casse.
+var reservedAliases = map[string]struct{}{
+	"health":      {},
+	"api":         {},
+	"admin":       {},
+	"metrics":     {},
+	"static":      {},
+	"assets":      {},
+	"favicon.ico": {},
+	"robots.txt":  {},
+}
+
+// CreateLinkOptions regroupe les paramètres optionnels de création d'un lien.
+type CreateLinkOptions struct {
+	Alias       string     // Alias personnalisé souhaité. Vide = code court généré aléatoirement.
+	ExpiresAt   *time.Time // Date d'expiration optionnelle. nil = le lien n'expire jamais.
+	MaxClicks   int        // Nombre maximal de redirections. 0 = illimité.
+	FallbackURL string     // URL de repli servie lorsque le moniteur signale l'URL longue en panne. Vide = aucune.
+}
+
+// validate vérifie la cohérence des options d'expiration.
+func (o CreateLinkOptions) validate(now time.Time) error {
+	if o.ExpiresAt != nil && !o.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidLinkOptions)
+	}
+	if o.MaxClicks < 0 {
+		return fmt.Errorf("%w: max_clicks must be positive", ErrInvalidLinkOptions)
+	}
+	return nil
+}
+
 // LinkService est une structure qui fournit des méthodes pour la logique métier des liens.
-// Elle détient linkRepo qui est une référence vers une interface LinkRepository.
+// Elle détient linkRepo qui est une référence vers une interface LinkRepository,
+// la politique à laquelle doivent se conformer les URLs de destination et la liste de blocage (optionnelle).
 type LinkService struct {
-	linkRepo repository.LinkRepository
+	linkRepo  repository.LinkRepository
+	policy    *urlpolicy.Policy
+	blocklist *blocklist.Blocklist
 }
 
 
 // NewLinkService crée et retourne une nouvelle instance de LinkService.
-func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
+// blocklist peut être nil si aucune liste de blocage n'est configurée.
+func NewLinkService(linkRepo repository.LinkRepository, policy *urlpolicy.Policy, blocklist *blocklist.Blocklist) *LinkService {
 	return &LinkService{
-		linkRepo: linkRepo,
+		linkRepo:  linkRepo,
+		policy:    policy,
+		blocklist: blocklist,
 	}
 }
 
+// checkDestination vérifie qu'une URL de destination (field: long_url ou fallback_url) respecte la politique
+// et ne figure pas dans la liste de blocage.
+func (s *LinkService) checkDestination(field, rawURL string) error {
+	if err := s.policy.Check(rawURL); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrURLRejected, field, err)
+	}
+	if s.blocklist != nil {
+		if entry, blocked := s.blocklist.Match(rawURL); blocked {
+			return fmt.Errorf("%w: %s matches '%s'", ErrURLBlocked, field, entry)
+		}
+	}
+	return nil
+}
+
 // GenerateShortCode est une méthode rattachée à LinkService
 // Elle génère un code court aléatoire d'une longueur spécifiée. Elle prend une longueur en paramètre et retourne une string et une erreur
 func (s *LinkService) GenerateShortCode (length int) (string, error) {
@@ -47,9 +115,101 @@ func (s *LinkService) GenerateShortCode (length int) (string, error) {
 }
 
 
-// CreateLink crée un nouveau lien raccourci.
-// Il génère un code court unique, puis persiste le lien dans la base de données.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+// ValidateAlias vérifie qu'un alias personnalisé respecte les règles de format
+// (longueur, jeu de caractères) et ne fait pas partie des mots réservés.
+func ValidateAlias(alias string) error {
+	if len(alias) < MinAliasLength || len(alias) > MaxAliasLength {
+		return fmt.Errorf("%w: must be between %d and %d characters long", ErrInvalidAlias, MinAliasLength, MaxAliasLength)
+	}
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: only letters, digits, '-' and '_' are allowed, and it must start with a letter or a digit", ErrInvalidAlias)
+	}
+	if _, reserved := reservedAliases[strings.ToLower(alias)]; reserved {
+		return fmt.Errorf("%w: '%s' is a reserved word", ErrInvalidAlias, alias)
+	}
+	return nil
+}
+
+// CreateLink crée un nouveau lien raccourci, appartenant à l'utilisateur du principal p.
+// Si opts.Alias est renseigné, il est validé puis utilisé tel quel comme code court ;
+// sinon un code court unique est généré. Le lien est ensuite persisté dans la base de données.
+func (s *LinkService) CreateLink(p Principal, longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	now := time.Now()
+	if err := opts.validate(now); err != nil {
+		return nil, err
+	}
+	if err := s.checkDestination("long_url", longURL); err != nil {
+		return nil, err
+	}
+	if opts.FallbackURL != "" {
+		if err := s.checkDestination("fallback_url", opts.FallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	shortCode := opts.Alias
+	if shortCode != "" {
+		if err := s.ensureAliasAvailable(shortCode); err != nil {
+			return nil, err
+		}
+	} else {
+		code, err := s.generateUniqueShortCode()
+		if err != nil {
+			return nil, err
+		}
+		shortCode = code
+	}
+
+	// Crée une nouvelle instance du modèle Link.
+	link := &models.Link{
+		Shortcode:   shortCode,
+		LongURL:     longURL,
+		CreatedAt:   now,
+		ExpiresAt:   opts.ExpiresAt,
+		MaxClicks:   opts.MaxClicks,
+		FallbackURL: opts.FallbackURL,
+		OwnerID:     p.UserID,
+	}
+
+	// Persiste le nouveau lien dans la base de données via le repository (CreateLink)
+	if err := s.linkRepo.CreateLink(link); err != nil {
+		// Un autre appel a pu réserver le même alias entre la vérification et l'insertion :
+		// la contrainte d'unicité de la base fait alors foi.
+		if opts.Alias != "" && isUniqueConstraintError(err) {
+			return nil, fmt.Errorf("%w: '%s'", ErrAliasTaken, opts.Alias)
+		}
+		return nil, fmt.Errorf("failed to create link: %w", err)
+	}
+
+	// Retourne le lien créé
+	return link, nil
+}
+
+// ensureAliasAvailable valide un alias personnalisé et vérifie qu'il n'est pas déjà pris.
+func (s *LinkService) ensureAliasAvailable(alias string) error {
+	if err := ValidateAlias(alias); err != nil {
+		return err
+	}
+
+	// Les liens supprimés logiquement réservent toujours leur code, afin de pouvoir être restaurés.
+	_, err := s.linkRepo.GetLinkByShortCodeUnscoped(alias)
+	if err == nil {
+		return fmt.Errorf("%w: '%s'", ErrAliasTaken, alias)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("database error checking alias availability: %w", err)
+	}
+	return nil
+}
+
+// isUniqueConstraintError indique si une erreur de persistance provient d'une violation d'unicité.
+func isUniqueConstraintError(err error) bool {
+	return errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// generateUniqueShortCode génère un code court aléatoire qui n'existe pas encore en base,
+// avec une logique de retry en cas de collision.
+func (s *LinkService) generateUniqueShortCode() (string, error) {
 	// Définir un nombre maximum (5) de tentative pour trouver un code unique  (maxRetries)
 	const maxRetries = 5
 	
@@ -60,12 +220,12 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 		// Génère un code de 6 caractères (GenerateShortCode)
 		code, err := s.GenerateShortCode(6)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate short code: %w", err)
+			return "", fmt.Errorf("failed to generate short code: %w", err)
 		}
 		
 		// Vérifie si le code généré existe déjà en base de données (GetLinkbyShortCode)
 		// On ignore la première valeur
-		_, err = s.linkRepo.GetLinkByShortCode(code)
+		_, err = s.linkRepo.GetLinkByShortCodeUnscoped(code)
 
 		if err != nil {
 			// Si l'erreur est 'record not found' de GORM, cela signifie que le code est unique.
@@ -74,32 +234,19 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 				break            // Sort de la boucle de retry
 			}
 			// Si c'est une autre erreur de base de données, retourne l'erreur.
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
+			return "", fmt.Errorf("database error checking short code uniqueness: %w", err)
 		}
 
 		// Si aucune erreur (le code a été trouvé), cela signifie une collision.
-		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
+		slog.Debug("Short code already exists, retrying generation", "short_code", code, "attempt", i+1, "max_retries", maxRetries)
 	}
 
 	// Si après toutes les tentatives, aucun code unique n'a été trouvé on génère une erreur.
 	if shortCode == "" {
-		return nil, errors.New("failed to generate a unique short code after maximum retries")
-	}
-
-	// Crée une nouvelle instance du modèle Link.
-	link := &models.Link{
-		Shortcode: shortCode,
-		LongURL:   longURL,
-		CreatedAt: time.Now(),
+		return "", errors.New("failed to generate a unique short code after maximum retries")
 	}
 
-	// Persiste le nouveau lien dans la base de données via le repository (CreateLink)
-	if err := s.linkRepo.CreateLink(link); err != nil {
-		return nil, fmt.Errorf("failed to create link: %w", err)
-	}
-
-	// Retourne le lien créé
-	return link, nil
+	return shortCode, nil
 }
 
 // GetLinkByShortCode récupère un lien via son code court.
@@ -114,17 +261,89 @@ func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error)
 	return link, nil
 }
 
+// ResolveLink récupère le lien à utiliser pour une redirection.
+// Il refuse les liens désactivés (ErrLinkDisabled), les liens en quarantaine (ErrLinkQuarantined),
+// les liens expirés (ErrLinkExpired) et consomme une unité du budget de clics
+// des liens limités, de façon atomique, avant d'autoriser la redirection (ErrClickLimitReached).
+// Les robots (isBot, générateurs d'aperçus de liens notamment) ne consomment pas le budget :
+// ils sont redirigés tant qu'il n'est pas épuisé, pour qu'un aperçu n'invalide pas un lien à usage unique.
+func (s *LinkService) ResolveLink(shortCode string, isBot bool) (*models.Link, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Disabled {
+		return link, ErrLinkDisabled
+	}
+
+	if link.Quarantined {
+		return link, ErrLinkQuarantined
+	}
+
+	if link.IsExpired(time.Now()) {
+		return link, ErrLinkExpired
+	}
+
+	if link.MaxClicks > 0 && isBot {
+		if link.UsedClicks >= link.MaxClicks {
+			return link, ErrClickLimitReached
+		}
+		return link, nil
+	}
+
+	if link.MaxClicks > 0 {
+		consumed, err := s.linkRepo.ConsumeClick(link.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !consumed {
+			return link, ErrClickLimitReached
+		}
+		link.UsedClicks++
+	}
+
+	return link, nil
+}
+
+// getAccessibleLink récupère un lien via son code court, y compris supprimé logiquement si includeDeleted vaut true,
+// et vérifie que le principal y a accès. Un lien d'un autre utilisateur est signalé comme inexistant (gorm.ErrRecordNotFound).
+func (s *LinkService) getAccessibleLink(p Principal, shortCode string, includeDeleted bool) (*models.Link, error) {
+	var link *models.Link
+	var err error
+	if includeDeleted {
+		link, err = s.linkRepo.GetLinkByShortCodeUnscoped(shortCode)
+	} else {
+		link, err = s.linkRepo.GetLinkByShortCode(shortCode)
+	}
+	if err == nil && !p.CanAccess(link) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link by short code %s: %w", shortCode, err)
+	}
+	return link, nil
+}
+
+// GetLinkForStats récupère un lien dont on veut consulter les statistiques détaillées.
+// Comme GetLinkStats, elle inclut les liens désactivés et supprimés logiquement.
+func (s *LinkService) GetLinkForStats(p Principal, shortCode string) (*models.Link, error) {
+	return s.getAccessibleLink(p, shortCode, true)
+}
+
 // GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
-// Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
-	// Récupérer le lien par son shortCode
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+// Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository.
+// Les liens désactivés ou supprimés logiquement restent consultables : leur historique est conservé.
+// Les clics de robots (aperçus de liens, crawlers) ne sont comptés que si includeBots vaut true.
+func (s *LinkService) GetLinkStats(p Principal, shortCode string, includeBots bool) (*models.Link, int, error) {
+	// Récupérer le lien par son shortCode, y compris s'il a été supprimé
+	link, err := s.getAccessibleLink(p, shortCode, true)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get link by short code %s: %w", shortCode, err)
+		return nil, 0, err
 	}
 
 	// Récupérer le nombre de clics associés à ce lien
-	count, err := s.linkRepo.CountClicksByLinkID(link.ID)
+	count, err := s.linkRepo.CountClicksByLinkID(link.ID, includeBots)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count clicks for link ID %d: %w", link.ID, err)
 	}
@@ -133,3 +352,253 @@ func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error)
 	return link, count, nil
 }
 
+// GetRoutingEvents retourne les limit dernières bascules de routage d'un lien (de la plus récente à la plus ancienne).
+// Comme GetLinkStats, elle inclut les liens désactivés et supprimés logiquement.
+func (s *LinkService) GetRoutingEvents(p Principal, shortCode string, limit int) ([]models.LinkRoutingEvent, error) {
+	link, err := s.getAccessibleLink(p, shortCode, true)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.linkRepo.GetRoutingEvents(link.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routing events for link ID %d: %w", link.ID, err)
+	}
+	return events, nil
+}
+
+// UpdateLinkOptions regroupe les modifications applicables à un lien existant.
+// Un champ nil n'est pas modifié.
+type UpdateLinkOptions struct {
+	LongURL     *string // Nouvelle URL de destination
+	Disabled    *bool   // true pour désactiver le lien, false pour le réactiver
+	FallbackURL *string // Nouvelle URL de repli ("" pour la retirer)
+	OwnerID     *uint   // Nouveau propriétaire (réservé aux administrateurs)
+}
+
+// UpdateLink modifie la destination et/ou l'état d'activation d'un lien existant.
+func (s *LinkService) UpdateLink(p Principal, shortCode string, opts UpdateLinkOptions) (*models.Link, error) {
+	if opts.OwnerID != nil && !p.Admin {
+		return nil, fmt.Errorf("%w: only administrators can change the owner of a link", ErrInvalidLinkOptions)
+	}
+
+	link, err := s.getAccessibleLink(p, shortCode, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.LongURL != nil {
+		if err := s.checkDestination("long_url", *opts.LongURL); err != nil {
+			return nil, err
+		}
+		// La libération d'une quarantaine valait pour l'ancienne destination.
+		if *opts.LongURL != link.LongURL {
+			link.QuarantineOverride = false
+		}
+		link.LongURL = *opts.LongURL
+	}
+	if opts.Disabled != nil {
+		link.Disabled = *opts.Disabled
+	}
+	if opts.FallbackURL != nil {
+		if *opts.FallbackURL != "" {
+			if err := s.checkDestination("fallback_url", *opts.FallbackURL); err != nil {
+				return nil, err
+			}
+		}
+		if *opts.FallbackURL != link.FallbackURL {
+			link.QuarantineOverride = false
+		}
+		link.FallbackURL = *opts.FallbackURL
+	}
+	if opts.OwnerID != nil {
+		link.OwnerID = opts.OwnerID
+	}
+
+	if err := s.linkRepo.UpdateLink(link); err != nil {
+		return nil, fmt.Errorf("failed to update link: %w", err)
+	}
+	return link, nil
+}
+
+// DeleteLink supprime logiquement un lien : il ne redirige plus,
+// mais son historique de clics reste disponible pour GetLinkStats.
+func (s *LinkService) DeleteLink(p Principal, shortCode string) error {
+	link, err := s.getAccessibleLink(p, shortCode, false)
+	if err != nil {
+		return err
+	}
+
+	if err := s.linkRepo.DeleteLink(link); err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	return nil
+}
+
+// RestoreLink annule la suppression logique d'un lien.
+// Il retourne ErrLinkNotDeleted si le lien n'a pas été supprimé.
+func (s *LinkService) RestoreLink(p Principal, shortCode string) (*models.Link, error) {
+	link, err := s.getAccessibleLink(p, shortCode, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !link.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: '%s'", ErrLinkNotDeleted, shortCode)
+	}
+
+	if err := s.linkRepo.RestoreLink(link); err != nil {
+		return nil, fmt.Errorf("failed to restore link: %w", err)
+	}
+	return link, nil
+}
+
+// ListQuarantinedLinks retourne les liens en quarantaine, à examiner par un administrateur.
+func (s *LinkService) ListQuarantinedLinks() ([]models.Link, error) {
+	return s.linkRepo.ListQuarantinedLinks()
+}
+
+// ReleaseQuarantine sort un lien de quarantaine après examen par un administrateur.
+// Le lien redirige de nouveau et n'est plus remis en quarantaine tant que ses URLs ne changent pas.
+// Il retourne ErrAdminRequired si le principal n'est pas administrateur, même propriétaire du lien,
+// et ErrLinkNotQuarantined si le lien n'est pas en quarantaine.
+func (s *LinkService) ReleaseQuarantine(p Principal, shortCode string) (*models.Link, error) {
+	link, err := s.getAccessibleLink(p, shortCode, true)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Admin {
+		return nil, fmt.Errorf("%w: releasing '%s' from quarantine", ErrAdminRequired, shortCode)
+	}
+	if !link.Quarantined {
+		return nil, fmt.Errorf("%w: '%s'", ErrLinkNotQuarantined, shortCode)
+	}
+
+	if err := s.linkRepo.ReleaseQuarantine(link); err != nil {
+		return nil, err
+	}
+	link.Quarantined = false
+	link.QuarantineOverride = true
+	return link, nil
+}
+
+// Bornes du nombre de liens retournés par page de listing.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListLinksParams regroupe les paramètres de listing des liens (API et CLI).
+type ListLinksParams struct {
+	Limit        int        // Taille de page (DefaultListLimit si 0, plafonnée à MaxListLimit)
+	Cursor       string     // Curseur opaque retourné par la page précédente
+	SortBy       string     // "created_at" (par défaut) ou "clicks"
+	Order        string     // "desc" (par défaut) ou "asc"
+	Domain       string     // Sous-chaîne recherchée dans le domaine de destination
+	CreatedAfter *time.Time // Ne retourne que les liens créés après cette date
+}
+
+// LinkPage est une page de résultats de ListLinks.
+// NextCursor est vide lorsqu'il n'y a plus de résultats.
+type LinkPage struct {
+	Links      []models.Link
+	NextCursor string
+}
+
+// listCursor est le contenu encodé dans le curseur opaque.
+// Le tri y est recopié pour refuser un curseur réutilisé avec un autre tri.
+type listCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d"`
+	repository.LinkCursor
+}
+
+// ListLinks retourne une page de liens triée et filtrée, avec le curseur de la page suivante.
+// Un principal non administrateur ne voit que ses propres liens.
+func (s *LinkService) ListLinks(p Principal, params ListLinksParams) (*LinkPage, error) {
+	query := repository.LinkListQuery{
+		Limit:          params.Limit,
+		SortBy:         params.SortBy,
+		DomainContains: params.Domain,
+		CreatedAfter:   params.CreatedAfter,
+	}
+	if !p.Admin {
+		query.Owner = &repository.LinkOwner{UserID: p.UserID}
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultListLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxListLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListParams, MaxListLimit)
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = repository.LinkSortCreatedAt
+	}
+	if query.SortBy != repository.LinkSortCreatedAt && query.SortBy != repository.LinkSortClicks {
+		return nil, fmt.Errorf("%w: sort must be '%s' or '%s'", ErrInvalidListParams, repository.LinkSortCreatedAt, repository.LinkSortClicks)
+	}
+
+	switch params.Order {
+	case "", "desc":
+		query.Descending = true
+	case "asc":
+		query.Descending = false
+	default:
+		return nil, fmt.Errorf("%w: order must be 'asc' or 'desc'", ErrInvalidListParams)
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeListCursor(params.Cursor)
+		if err != nil || cursor.SortBy != query.SortBy || cursor.Descending != query.Descending {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidListParams)
+		}
+		query.After = &cursor.LinkCursor
+	}
+
+	// Un principal sans utilisateur n'a accès à aucun lien : les liens sans propriétaire sont réservés aux administrateurs.
+	if query.Owner != nil && query.Owner.UserID == nil {
+		return &LinkPage{}, nil
+	}
+
+	// On lit un élément de plus que demandé pour savoir s'il existe une page suivante.
+	requested := query.Limit
+	query.Limit++
+	links, err := s.linkRepo.ListLinks(query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &LinkPage{Links: links}
+	if len(links) > requested {
+		page.Links = links[:requested]
+		last := page.Links[requested-1]
+		page.NextCursor = encodeListCursor(listCursor{
+			SortBy:     query.SortBy,
+			Descending: query.Descending,
+			LinkCursor: repository.LinkCursor{
+				CreatedAtUnix: last.CreatedAt.Unix(),
+				ClickCount:    last.ClickCount,
+				ID:            last.ID,
+			},
+		})
+	}
+	return page, nil
+}
+
+// encodeListCursor sérialise un curseur en une chaîne opaque utilisable dans une URL.
+func encodeListCursor(cursor listCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeListCursor désérialise un curseur produit par encodeListCursor.
+func decodeListCursor(token string) (listCursor, error) {
+	var cursor listCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(raw, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+	"gorm.io/gorm"
+)
+
+func TestResolveLinkDoesNotChargeBots(t *testing.T) {
+	db := repositorytest.OpenDatabase(t)
+
+	linkRepo := repository.NewLinkRepository(db)
+	if err := linkRepo.CreateLink(&models.Link{Shortcode: "once", LongURL: "https://example.com", MaxClicks: 1}); err != nil {
+		t.Fatal(err)
+	}
+	service := NewLinkService(linkRepo, nil, nil)
+
+	// Un aperçu de lien, le destinataire, puis de nouveau un robot et un visiteur une fois le budget épuisé.
+	steps := []struct {
+		name    string
+		isBot   bool
+		wantErr error
+		used    int
+	}{
+		{"preview bot", true, nil, 0},
+		{"visitor", false, nil, 1},
+		{"bot after last click", true, ErrClickLimitReached, 1},
+		{"visitor after last click", false, ErrClickLimitReached, 1},
+	}
+	for _, step := range steps {
+		_, err := service.ResolveLink("once", step.isBot)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: ResolveLink() error = %v, want %v", step.name, err, step.wantErr)
+		}
+		link, err := linkRepo.GetLinkByShortCode("once")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.UsedClicks != step.used {
+			t.Errorf("%s: UsedClicks = %d, want %d", step.name, link.UsedClicks, step.used)
+		}
+	}
+}
+
+func TestPrincipalCanAccess(t *testing.T) {
+	alice, bob := uint(1), uint(2)
+	owned := &models.Link{OwnerID: &alice}
+	ownerless := &models.Link{}
+
+	tests := []struct {
+		name      string
+		principal Principal
+		link      *models.Link
+		want      bool
+	}{
+		{"owner", Principal{UserID: &alice}, owned, true},
+		{"other user", Principal{UserID: &bob}, owned, false},
+		{"admin", Principal{Admin: true}, owned, true},
+		{"no user", Principal{}, owned, false},
+		// Les liens sans propriétaire sont réservés aux administrateurs.
+		{"ownerless for no user", Principal{}, ownerless, false},
+		{"ownerless for user", Principal{UserID: &alice}, ownerless, false},
+		{"ownerless for admin", Principal{Admin: true}, ownerless, true},
+	}
+	for _, tt := range tests {
+		if got := tt.principal.CanAccess(tt.link); got != tt.want {
+			t.Errorf("%s: CanAccess() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLinkServiceEnforcesPrincipal(t *testing.T) {
+	db := repositorytest.OpenDatabase(t)
+	linkRepo := repository.NewLinkRepository(db)
+	alice, bob := uint(1), uint(2)
+	links := []*models.Link{
+		{Shortcode: "alice", LongURL: "https://example.com/a", OwnerID: &alice},
+		{Shortcode: "legacy", LongURL: "https://example.com/l"},
+	}
+	for _, link := range links {
+		if err := linkRepo.CreateLink(link); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := linkRepo.QuarantineLink(link.ID, "example.com", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service := NewLinkService(linkRepo, nil, nil)
+
+	tests := []struct {
+		name      string
+		principal Principal
+		shortCode string
+		wantErr   error // Erreur attendue pour GetRoutingEvents (nil si accessible)
+	}{
+		{"owner", Principal{UserID: &alice}, "alice", nil},
+		{"other user", Principal{UserID: &bob}, "alice", gorm.ErrRecordNotFound},
+		{"ownerless for user", Principal{UserID: &alice}, "legacy", gorm.ErrRecordNotFound},
+		{"ownerless for no user", Principal{}, "legacy", gorm.ErrRecordNotFound},
+		{"admin", Principal{Admin: true}, "legacy", nil},
+	}
+	for _, tt := range tests {
+		if _, err := service.GetRoutingEvents(tt.principal, tt.shortCode, 10); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: GetRoutingEvents(%s) error = %v, want %v", tt.name, tt.shortCode, err, tt.wantErr)
+		}
+	}
+
+	// Libérer un lien de quarantaine reste réservé aux administrateurs, même pour son propriétaire.
+	if _, err := service.ReleaseQuarantine(Principal{UserID: &bob}, "alice"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("ReleaseQuarantine() by another user error = %v, want gorm.ErrRecordNotFound", err)
+	}
+	if _, err := service.ReleaseQuarantine(Principal{UserID: &alice}, "alice"); !errors.Is(err, ErrAdminRequired) {
+		t.Errorf("ReleaseQuarantine() by the owner error = %v, want ErrAdminRequired", err)
+	}
+	link, err := service.ReleaseQuarantine(SystemPrincipal, "alice")
+	if err != nil {
+		t.Fatalf("ReleaseQuarantine() by an admin error = %v", err)
+	}
+	if link.Quarantined || !link.QuarantineOverride {
+		t.Errorf("released link: quarantined = %v, override = %v; want false, true", link.Quarantined, link.QuarantineOverride)
+	}
+
+	// Un principal sans utilisateur ne liste aucun lien, pas même ceux sans propriétaire.
+	page, err := service.ListLinks(Principal{}, ListLinksParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Links) != 0 {
+		t.Errorf("ListLinks() without user returned %d links, want none", len(page.Links))
+	}
+}
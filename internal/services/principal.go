@@ -0,0 +1,34 @@
+package services
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Principal est l'identité au nom de laquelle une opération sur les liens est effectuée.
+// Un principal non administrateur ne voit que les liens de son utilisateur : ceux des autres
+// sont traités comme inexistants (gorm.ErrRecordNotFound), pour ne pas révéler leur existence.
+// Les liens sans propriétaire sont réservés aux administrateurs.
+type Principal struct {
+	UserID *uint // Utilisateur propriétaire des liens créés (nil = aucun lien accessible hors administration)
+	Admin  bool  // Accès à tous les liens, quel que soit leur propriétaire
+}
+
+// SystemPrincipal est le principal de la CLI : elle accède directement à la base et voit tous les liens.
+var SystemPrincipal = Principal{Admin: true}
+
+// PrincipalForKey retourne le principal d'une clé d'API authentifiée : son utilisateur,
+// administrateur si la clé porte le scope admin.
+func PrincipalForKey(key *models.APIKey) Principal {
+	return Principal{UserID: key.UserID, Admin: key.HasScope(models.ScopeAdmin)}
+}
+
+// CanAccess indique si le principal peut consulter et modifier le lien.
+// Hors administration, il faut que le lien appartienne à l'utilisateur du principal :
+// un principal sans utilisateur n'accède à aucun lien, et personne d'autre qu'un administrateur
+// n'accède aux liens sans propriétaire.
+func (p Principal) CanAccess(link *models.Link) bool {
+	if p.Admin {
+		return true
+	}
+	return p.UserID != nil && link.OwnerID != nil && *p.UserID == *link.OwnerID
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Granularités acceptées pour les séries temporelles de clics.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// MaxTimeSeriesBuckets limite la taille d'une série pour éviter des réponses démesurées
+// (ex: une granularité horaire sur plusieurs années).
+const MaxTimeSeriesBuckets = 2000
+
+// TimeBucket est un intervalle de la série temporelle et son nombre de clics.
+type TimeBucket struct {
+	Start  time.Time
+	Clicks int
+}
+
+// GetClickTimeSeries retourne le nombre de clics d'un lien par heure, jour ou semaine
+// sur la période du filtre. Les intervalles sont alignés dans le fuseau loc
+// (minuit local, semaines commençant le lundi) et les intervalles sans clic valent zéro.
+func (s *ClickService) GetClickTimeSeries(linkID uint, filter repository.ClickFilter, interval string, loc *time.Location) ([]TimeBucket, error) {
+	if !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidStatsParams)
+	}
+
+	buckets, err := buildBuckets(filter.From, filter.To, interval, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	hourly, err := s.clickRepo.CountClicksByHour(linkID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time series for link ID %d: %w", linkID, err)
+	}
+
+	for _, h := range hourly {
+		addToBucket(buckets, h.Hour, h.Clicks)
+	}
+	return buckets, nil
+}
+
+// buildBuckets génère les intervalles vides couvrant [from, to) pour une granularité donnée.
+func buildBuckets(from, to time.Time, interval string, loc *time.Location) ([]TimeBucket, error) {
+	var step func(time.Time) time.Time
+	switch interval {
+	case IntervalHour:
+		step = func(t time.Time) time.Time { return t.Add(time.Hour) }
+	case IntervalDay:
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case IntervalWeek:
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	default:
+		return nil, fmt.Errorf("%w: interval must be '%s', '%s' or '%s'", ErrInvalidStatsParams, IntervalHour, IntervalDay, IntervalWeek)
+	}
+
+	var buckets []TimeBucket
+	for start := truncateToInterval(from.In(loc), interval); start.Before(to); start = step(start) {
+		if len(buckets) == MaxTimeSeriesBuckets {
+			return nil, fmt.Errorf("%w: too many buckets (max %d), use a larger interval or a shorter period", ErrInvalidStatsParams, MaxTimeSeriesBuckets)
+		}
+		buckets = append(buckets, TimeBucket{Start: start})
+	}
+	return buckets, nil
+}
+
+// truncateToInterval ramène une date au début de son heure, de son jour ou de sa semaine (lundi) dans son fuseau.
+func truncateToInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case IntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		offset := (int(day.Weekday()) + 6) % 7 // Nombre de jours écoulés depuis lundi
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// addToBucket ajoute des clics à l'intervalle contenant l'instant at.
+// Les instants antérieurs au premier intervalle (fuseaux à décalage non entier) y sont rattachés.
+func addToBucket(buckets []TimeBucket, at time.Time, clicks int) {
+	if len(buckets) == 0 {
+		return
+	}
+	i := sort.Search(len(buckets), func(i int) bool { return buckets[i].Start.After(at) }) - 1
+	if i < 0 {
+		i = 0
+	}
+	buckets[i].Clicks += clicks
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// userNamePattern reprend les règles des alias : lettres, chiffres, tirets, underscores et points.
+var userNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,99}$`)
+
+// UserService gère les utilisateurs propriétaires des liens.
+type UserService struct {
+	userRepo repository.UserRepository
+}
+
+// NewUserService crée et retourne une nouvelle instance de UserService.
+func NewUserService(userRepo repository.UserRepository) *UserService {
+	return &UserService{userRepo: userRepo}
+}
+
+// CreateUser crée un utilisateur. Il retourne ErrInvalidUser si le nom est invalide et ErrUserExists s'il est déjà pris.
+func (s *UserService) CreateUser(name string) (*models.User, error) {
+	if !userNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: name must be 1 to 100 letters, digits, '.', '-' or '_'", ErrInvalidUser)
+	}
+	user := &models.User{Name: name}
+	if err := s.userRepo.CreateUser(user); err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, fmt.Errorf("%w: '%s'", ErrUserExists, name)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByName récupère un utilisateur par son nom (gorm.ErrRecordNotFound s'il n'existe pas).
+func (s *UserService) GetUserByName(name string) (*models.User, error) {
+	return s.userRepo.GetUserByName(name)
+}
+
+// ListUsers retourne tous les utilisateurs.
+func (s *UserService) ListUsers() ([]models.User, error) {
+	return s.userRepo.ListUsers()
+}
@@ -0,0 +1,65 @@
+package urlpolicy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress est retournée par le dialer de NewDialer lorsqu'une connexion vise une adresse non publique.
+var ErrForbiddenAddress = errors.New("connection to non-public address refused")
+
+// nonPublicPrefixes complète les tests de netip.Addr (bouclage, privé, lien-local, multicast)
+// avec les plages réservées qui ne désignent pas un hôte d'Internet.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "Ce réseau"
+	netip.MustParsePrefix("100.64.0.0/10"),  // NAT des opérateurs (CGNAT)
+	netip.MustParsePrefix("192.0.0.0/24"),   // Affectations de protocoles IETF
+	netip.MustParsePrefix("198.18.0.0/15"),  // Tests de performance réseau
+	netip.MustParsePrefix("240.0.0.0/4"),    // Réservé, diffusion comprise
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64 : peut traduire vers une adresse IPv4 interne
+	netip.MustParsePrefix("64:ff9b:1::/48"), // NAT64 local
+	netip.MustParsePrefix("2002::/16"),      // 6to4 : encapsule une adresse IPv4 arbitraire
+	netip.MustParsePrefix("2001:db8::/32"),  // Documentation
+	netip.MustParsePrefix("100::/64"),       // Trou noir (discard)
+	netip.MustParsePrefix("2001::/32"),      // Teredo : encapsule une adresse IPv4 arbitraire
+}
+
+// IsPublicAddr indique si une adresse IP désigne un hôte d'Internet, et non le réseau local,
+// l'hôte lui-même ou une plage réservée (les adresses IPv4 écrites en IPv6 sont testées comme IPv4).
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewDialer retourne un dialer qui refuse les connexions vers les adresses non publiques (ErrForbiddenAddress).
+// Le contrôle porte sur l'adresse effectivement contactée, après résolution DNS : un nom de domaine public
+// pointant vers 127.0.0.1 ou 169.254.169.254 est refusé, y compris au détour d'une redirection.
+func NewDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !IsPublicAddr(addr) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+}
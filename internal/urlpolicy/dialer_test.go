@@ -0,0 +1,95 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:93.184.216.34", true},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"2002:7f00:1::", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestDialerRefusesNonPublicAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{DialContext: NewDialer(time.Second).DialContext}}
+	// Le nom "localhost" passe la résolution DNS : c'est l'adresse obtenue (127.0.0.1 ou ::1) qui est refusée.
+	for _, host := range []string{"localhost", "127.0.0.1", "::ffff:127.0.0.1"} {
+		t.Run(host, func(t *testing.T) {
+			resp, err := client.Get("http://" + net.JoinHostPort(host, port) + "/")
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("GET %s succeeded, want the connection refused", host)
+			}
+			if !errors.Is(err, ErrForbiddenAddress) {
+				t.Errorf("GET %s error = %v, want ErrForbiddenAddress", host, err)
+			}
+		})
+	}
+
+	// Le même serveur est joignable sans le contrôle : le refus vient bien du dialer.
+	plain := &net.Dialer{Timeout: time.Second}
+	conn, err := plain.DialContext(context.Background(), "tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("plain dial error = %v", err)
+	}
+	conn.Close()
+}
+
+func TestDialerControlChecksResolvedAddress(t *testing.T) {
+	// Control reçoit l'adresse obtenue après résolution DNS, quel que soit le nom demandé.
+	control := NewDialer(time.Second).Control
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"10.0.0.1:80", true},
+		{"192.168.1.1:443", true},
+		{"169.254.169.254:80", true},
+		{"[fd00::1]:443", true},
+		{"93.184.216.34:443", false},
+	}
+	for _, tt := range tests {
+		err := control("tcp", tt.address, nil)
+		if tt.wantErr != errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Control(%s) error = %v, want refused = %v", tt.address, err, tt.wantErr)
+		}
+	}
+}
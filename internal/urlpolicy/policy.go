@@ -0,0 +1,191 @@
+// Package urlpolicy définit les URLs de destination acceptées par le service (schéma, longueur,
+// nom de domaine, réseaux privés) et fournit un dialer refusant les adresses non publiques,
+// pour que le moniteur ne puisse pas servir à sonder le réseau interne (SSRF).
+package urlpolicy
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// Valeurs par défaut de la politique.
+const DefaultMaxLength = 2048
+
+// DefaultSchemes sont les schémas acceptés si la configuration n'en précise pas.
+var DefaultSchemes = []string{"http", "https"}
+
+// Options configure une Policy.
+type Options struct {
+	AllowedSchemes       []string // Schémas acceptés (DefaultSchemes si vide)
+	MaxLength            int      // Longueur maximale d'une URL (DefaultMaxLength si 0)
+	AllowedDomains       []string // Si non vide, seuls ces domaines (et leurs sous-domaines) sont acceptés
+	DeniedDomains        []string // Domaines (et sous-domaines) refusés, prioritaires sur AllowedDomains
+	AllowPrivateNetworks bool     // Accepte localhost et les adresses privées, de bouclage ou lien-local (développement)
+}
+
+// Policy valide les URLs de destination des liens. Elle est sûre pour un usage concurrent.
+type Policy struct {
+	schemes      map[string]struct{}
+	maxLength    int
+	allowed      []string
+	denied       []string
+	allowPrivate bool
+}
+
+// New crée une Policy. Une erreur est retournée si un domaine des listes est invalide.
+func New(opts Options) (*Policy, error) {
+	if len(opts.AllowedSchemes) == 0 {
+		opts.AllowedSchemes = DefaultSchemes
+	}
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = DefaultMaxLength
+	}
+
+	p := &Policy{
+		schemes:      make(map[string]struct{}, len(opts.AllowedSchemes)),
+		maxLength:    opts.MaxLength,
+		allowPrivate: opts.AllowPrivateNetworks,
+	}
+	for _, scheme := range opts.AllowedSchemes {
+		p.schemes[strings.ToLower(scheme)] = struct{}{}
+	}
+	var err error
+	if p.allowed, err = normalizeDomains(opts.AllowedDomains); err != nil {
+		return nil, err
+	}
+	if p.denied, err = normalizeDomains(opts.DeniedDomains); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check retourne une erreur décrivant la règle enfreinte si rawURL n'est pas une destination acceptable.
+func (p *Policy) Check(rawURL string) error {
+	if len(rawURL) > p.maxLength {
+		return fmt.Errorf("url is longer than %d characters", p.maxLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("malformed url: %w", err)
+	}
+	if _, ok := p.schemes[strings.ToLower(u.Scheme)]; !ok {
+		return fmt.Errorf("scheme '%s' is not allowed", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("credentials in url are not allowed")
+	}
+	host := strings.TrimSuffix(u.Hostname(), ".")
+	if host == "" {
+		return errors.New("url has no host")
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !p.allowPrivate && !IsPublicAddr(addr) {
+			return fmt.Errorf("address %s is not public", addr)
+		}
+		return p.checkDomainLists(addr.String())
+	}
+
+	ascii, err := checkHostname(host)
+	if err != nil {
+		return err
+	}
+	if !p.allowPrivate && (ascii == "localhost" || strings.HasSuffix(ascii, ".localhost")) {
+		return fmt.Errorf("host %s is not public", ascii)
+	}
+	return p.checkDomainLists(ascii)
+}
+
+// checkHostname vérifie un nom d'hôte et retourne sa forme ASCII (punycode).
+// Les noms internationalisés doivent respecter IDNA 2008, et chacun de leurs labels ne doit pas
+// mélanger les alphabets latin, cyrillique et grec, procédé classique d'usurpation ("pаypal" avec un "а" cyrillique).
+func checkHostname(host string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid internationalized domain name '%s': %w", host, err)
+	}
+	labels := strings.Split(ascii, ".")
+	if last := labels[len(labels)-1]; strings.Trim(last, "0123456789") == "" {
+		// "http://2852039166/" ou "http://0251.0376.0251.0376/" sont des écritures d'adresses IPv4 pour les navigateurs.
+		return "", fmt.Errorf("host '%s' looks like an ambiguous ip address", host)
+	}
+
+	unicodeHost, err := idna.Lookup.ToUnicode(ascii)
+	if err != nil {
+		return "", fmt.Errorf("invalid internationalized domain name '%s': %w", host, err)
+	}
+	for _, label := range strings.Split(unicodeHost, ".") {
+		if mixesScripts(label) {
+			return "", fmt.Errorf("domain name '%s' mixes latin, cyrillic or greek letters", unicodeHost)
+		}
+	}
+	return ascii, nil
+}
+
+// confusableScripts sont les alphabets dont les lettres se ressemblent au point de permettre l'usurpation d'un domaine.
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek}
+
+// mixesScripts indique si un label contient des lettres de plusieurs alphabets de confusableScripts.
+func mixesScripts(label string) bool {
+	var seen *unicode.RangeTable
+	for _, r := range label {
+		for _, script := range confusableScripts {
+			if !unicode.Is(script, r) {
+				continue
+			}
+			if seen != nil && seen != script {
+				return true
+			}
+			seen = script
+		}
+	}
+	return false
+}
+
+// checkDomainLists applique les listes de domaines refusés puis autorisés à un hôte normalisé.
+func (p *Policy) checkDomainLists(host string) error {
+	if matchesDomain(host, p.denied) {
+		return fmt.Errorf("domain %s is denied", host)
+	}
+	if len(p.allowed) > 0 && !matchesDomain(host, p.allowed) {
+		return fmt.Errorf("domain %s is not in the allowed list", host)
+	}
+	return nil
+}
+
+// matchesDomain indique si host est l'un des domaines donnés ou l'un de leurs sous-domaines.
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomains met les domaines des listes sous leur forme ASCII en minuscules, comme les hôtes comparés.
+func normalizeDomains(domains []string) ([]string, error) {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.Trim(strings.TrimSpace(domain), ".")
+		if domain == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(domain); err == nil {
+			normalized = append(normalized, addr.String())
+			continue
+		}
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid domain '%s' in url policy: %w", domain, err)
+		}
+		normalized = append(normalized, ascii)
+	}
+	return normalized, nil
+}
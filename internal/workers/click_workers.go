@@ -1,46 +1,140 @@
 package workers
 
 import (
-	"log"
+	"time"
 
-	"github.com/axellelanca/urlshortener/internal/api"
 	"github.com/axellelanca/urlshortener/internal/models"
-	"github.com/axellelanca/urlshortener/internal/repository"
 )
 
-// StartClickWorkers lance un pool de goroutines "workers" pour traiter les événements de clic.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan api.ClickEvent, clickRepo repository.ClickRepository, linkRepo repository.LinkRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
-	for i := 0; i < workerCount; i++ {
-		go clickWorker(clickEventsChan, clickRepo, linkRepo)
+// Valeurs par défaut du regroupement des clics si la configuration ne les précise pas.
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 500 * time.Millisecond
+)
+
+// Nouvelles tentatives d'écriture d'un lot en échec (base verrouillée, erreur d'E/S passagère...).
+// Le délai entre deux tentatives double à chaque fois : 100 ms, 200 ms puis 400 ms.
+const (
+	batchWriteRetries = 3
+	batchRetryBackoff = 100 * time.Millisecond
+)
+
+// BatchOptions règle le regroupement des clics avant leur persistance.
+// Un lot est écrit dès qu'il atteint Size clics, ou au plus tard FlushInterval après son premier clic.
+type BatchOptions struct {
+	Size          int
+	FlushInterval time.Duration
+}
+
+// withDefaults remplace les valeurs nulles ou négatives par les valeurs par défaut.
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.Size <= 0 {
+		o.Size = DefaultBatchSize
 	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = DefaultFlushInterval
+	}
+	return o
 }
 
-// clickWorker traite les événements du channel.
-func clickWorker(clickEventsChan <-chan api.ClickEvent, clickRepo repository.ClickRepository, linkRepo repository.LinkRepository) {
-	for event := range clickEventsChan {
-		// Récupérer le LinkID à partir du ShortCode
-		link, err := linkRepo.GetLinkByShortCode(event.ShortCode)
-		if err != nil {
-			log.Printf("ERROR: Failed to get link for short code %s: %v", event.ShortCode, err)
-			continue
+// clickWorker traite les événements du channel et les persiste par lots.
+// Le lot en cours est écrit lorsqu'il est plein, lorsque le délai maximal depuis son premier clic
+// est écoulé, ou lorsque le channel est fermé.
+func (p *ClickPipeline) clickWorker() {
+	defer p.wg.Done()
+
+	batch := make([]models.Click, 0, p.opts.Batch.Size)
+	var journalSeqs []uint64 // Événements journalisés du lot, acquittés après persistance
+	var requestIDs []string  // Requêtes de redirection à l'origine des clics du lot, pour le diagnostic
+
+	// Le timer n'est armé que lorsqu'un lot est en cours.
+	flushTimer := time.NewTimer(p.opts.Batch.FlushInterval)
+	flushTimer.Stop()
+
+	flush := func() {
+		flushTimer.Stop()
+		if len(batch) == 0 {
+			return
 		}
+		if err := p.saveBatch(batch); err != nil {
+			logger().Error("Failed to save click batch, giving up",
+				"clicks", len(batch), "request_ids", requestIDs, "journal_seqs", journalSeqs, "error", err)
+			p.failed.Add(int64(len(batch)))
+		} else {
+			logger().Debug("Click batch recorded", "clicks", len(batch))
+			p.recorded.Add(int64(len(batch)))
+		}
+		// Un lot abandonné est aussi acquitté : sinon le journal ne pourrait plus avancer sa limite
+		// d'acquittement, conserverait tous ses segments et rejouerait ce lot à chaque démarrage.
+		if p.journal != nil && len(journalSeqs) > 0 {
+			p.journal.Ack(journalSeqs...)
+		}
+		batch = make([]models.Click, 0, p.opts.Batch.Size)
+		journalSeqs = journalSeqs[:0]
+		requestIDs = requestIDs[:0]
+	}
+
+	for {
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				flush()
+				return
+			}
+			p.received.Add(1)
+
+			// Les événements journalisés avant que Enqueue ne protège l'adresse IP sont traités ici.
+			p.enricher.ProtectEvent(&event)
 
-		// Conversion api.ClickEvent -> models.Click
-		click := models.Click{
-			LinkID:    link.ID,
-			Timestamp: event.Timestamp,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IP,
+			// Conversion models.ClickEvent -> models.Click
+			click := models.Click{
+				LinkID:    event.LinkID,
+				Timestamp: event.Timestamp.UTC(), // Stocké en UTC pour que les filtres par période soient comparables
+				UserAgent: event.UserAgent,
+				IPAddress: event.IP,
+				Referrer:  event.Referrer,
+				Country:   event.Country,
+				Region:    event.Region,
+				City:      event.City,
+			}
+
+			// Analyse du User-Agent et du référent à l'ingestion (sans appel réseau)
+			p.enricher.Enrich(&click)
+			// La redirection a déjà classé le clic sur le User-Agent complet : sous DNT/GPC,
+			// UserAgent est vide et une nouvelle analyse compterait les robots comme des visiteurs.
+			click.IsBot = event.IsBot
+
+			batch = append(batch, click)
+			if event.JournalSeq != 0 {
+				journalSeqs = append(journalSeqs, event.JournalSeq)
+			}
+			if event.RequestID != "" {
+				requestIDs = append(requestIDs, event.RequestID)
+			}
+			if len(batch) == 1 {
+				flushTimer.Reset(p.opts.Batch.FlushInterval)
+			}
+			if len(batch) >= p.opts.Batch.Size {
+				flush()
+			}
+		case <-flushTimer.C:
+			flush()
 		}
+	}
+}
 
-		// Appel à la persistance (il faut que clickRepo ait la méthode CreateClick)
-		err = clickRepo.CreateClick(&click)
-		if err != nil {
-			log.Printf("ERROR: Failed to save click for LinkID %d (UserAgent: %s, IP: %s): %v",
-				link.ID, event.UserAgent, event.IP, err)
-		} else {
-			log.Printf("Click recorded successfully for LinkID %d", link.ID)
+// saveBatch écrit un lot de clics, en le retentant jusqu'à batchWriteRetries fois avec un délai croissant.
+// Le lot étant inséré en une seule transaction, une tentative en échec n'a rien écrit : le rejouer ne crée pas de doublon.
+func (p *ClickPipeline) saveBatch(batch []models.Click) error {
+	backoff := batchRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.clickRepo.CreateClicks(batch)
+		if err == nil || attempt > batchWriteRetries {
+			return err
 		}
+		logger().Warn("Failed to save click batch, retrying",
+			"clicks", len(batch), "attempt", attempt, "retry_in", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
 	}
 }
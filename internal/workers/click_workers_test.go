@@ -0,0 +1,220 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/journal"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/repository/repositorytest"
+)
+
+// silenceLogs coupe les logs du pipeline pendant le test.
+func silenceLogs(tb testing.TB) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	tb.Cleanup(func() { slog.SetDefault(previous) })
+}
+
+// BenchmarkClickWorkerBatchSize compare le débit d'écriture des clics sans regroupement
+// (un clic par transaction, comportement antérieur aux lots) et avec la taille de lot par défaut.
+// Chaque itération passe un clic dans le pipeline jusqu'à sa persistance dans SQLite.
+//
+//	go test ./internal/workers -run '^$' -bench ClickWorker
+func BenchmarkClickWorkerBatchSize(b *testing.B) {
+	for _, size := range []int{1, DefaultBatchSize} {
+		b.Run(fmt.Sprintf("batch=%d", size), func(b *testing.B) {
+			silenceLogs(b)
+			db := repositorytest.OpenDatabase(b)
+			link := models.Link{Shortcode: "bench", LongURL: "https://example.com"}
+			if err := db.Create(&link).Error; err != nil {
+				b.Fatal(err)
+			}
+
+			pipeline := NewClickPipeline(repository.NewClickRepository(db), analytics.NewEnricher(nil, nil), PipelineOptions{
+				WorkerCount: DefaultWorkerCount,
+				BufferSize:  b.N, // Aucun clic abandonné : on mesure l'écriture, pas la saturation du channel
+				Batch:       BatchOptions{Size: size, FlushInterval: DefaultFlushInterval},
+			})
+
+			b.ResetTimer()
+			start := time.Now()
+			pipeline.Start()
+			for i := 0; i < b.N; i++ {
+				pipeline.Enqueue(models.ClickEvent{
+					LinkID:    link.ID,
+					Timestamp: time.Now(),
+					UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/126.0",
+					IP:        "203.0.113.7",
+				})
+			}
+			if err := pipeline.Stop(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "clicks/s")
+
+			if stats := pipeline.Stats(); stats.Recorded != int64(b.N) {
+				b.Fatalf("recorded %d clicks, want %d (stats: %+v)", stats.Recorded, b.N, stats)
+			}
+		})
+	}
+}
+
+// flakyClickRepository fait échouer les failures premières écritures de lots.
+type flakyClickRepository struct {
+	repository.ClickRepository
+	failures int
+	calls    int
+}
+
+func (r *flakyClickRepository) CreateClicks(clicks []models.Click) error {
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("database is locked")
+	}
+	return r.ClickRepository.CreateClicks(clicks)
+}
+
+func TestClickWorkerRetriesFailedBatch(t *testing.T) {
+	silenceLogs(t)
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantRecorded int64
+		wantFailed   int64
+	}{
+		{"transient error", 2, 3, 0},
+		{"persistent error", batchWriteRetries + 1, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &flakyClickRepository{ClickRepository: repository.NewClickRepository(repositorytest.OpenDatabase(t)), failures: tt.failures}
+			pipeline := NewClickPipeline(repo, analytics.NewEnricher(nil, nil), PipelineOptions{
+				WorkerCount: 1,
+				Batch:       BatchOptions{Size: 3, FlushInterval: time.Minute},
+			})
+			pipeline.Start()
+			for i := 0; i < 3; i++ {
+				pipeline.Enqueue(models.ClickEvent{LinkID: 1, Timestamp: time.Now()})
+			}
+			if err := pipeline.Stop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			stats := pipeline.Stats()
+			if stats.Recorded != tt.wantRecorded || stats.Failed != tt.wantFailed {
+				t.Errorf("recorded=%d failed=%d; want recorded=%d failed=%d", stats.Recorded, stats.Failed, tt.wantRecorded, tt.wantFailed)
+			}
+			if want := min(tt.failures+1, batchWriteRetries+1); repo.calls != want {
+				t.Errorf("CreateClicks called %d times; want %d", repo.calls, want)
+			}
+		})
+	}
+}
+
+func TestClickWorkerKeepsBotFlagFromRedirect(t *testing.T) {
+	silenceLogs(t)
+
+	db := repositorytest.OpenDatabase(t)
+	pipeline := NewClickPipeline(repository.NewClickRepository(db), analytics.NewEnricher(nil, nil), PipelineOptions{
+		WorkerCount: 1,
+		Batch:       BatchOptions{Size: 2, FlushInterval: time.Minute},
+	})
+	pipeline.Start()
+	// Un robot sous DNT arrive sans User-Agent : seul le drapeau de la redirection le signale.
+	pipeline.Enqueue(models.ClickEvent{LinkID: 1, Timestamp: time.Now(), IsBot: true})
+	pipeline.Enqueue(models.ClickEvent{LinkID: 1, Timestamp: time.Now(), UserAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/126.0"})
+	if err := pipeline.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var clicks []models.Click
+	if err := db.Order("id").Find(&clicks).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(clicks) != 2 {
+		t.Fatalf("recorded %d clicks, want 2", len(clicks))
+	}
+	if !clicks[0].IsBot || clicks[1].IsBot {
+		t.Errorf("IsBot = %v, %v; want true, false", clicks[0].IsBot, clicks[1].IsBot)
+	}
+	if clicks[1].Browser != "Firefox" {
+		t.Errorf("Browser = %q, want Firefox (the user agent is still parsed)", clicks[1].Browser)
+	}
+}
+
+// openTestJournal ouvre un journal de clics dans un dossier temporaire, fermé à la fin du test.
+func openTestJournal(t *testing.T) (*journal.Journal, string) {
+	t.Helper()
+	dir := t.TempDir()
+	j, err := journal.Open(journal.Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j, dir
+}
+
+func TestEnqueueJournalsAnonymizedIP(t *testing.T) {
+	silenceLogs(t)
+
+	j, dir := openTestJournal(t)
+	anonymizer, err := analytics.NewAnonymizer(analytics.IPModeTruncate, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := NewClickPipeline(repository.NewClickRepository(repositorytest.OpenDatabase(t)), analytics.NewEnricher(nil, anonymizer), PipelineOptions{Journal: j})
+
+	if !pipeline.Enqueue(models.ClickEvent{LinkID: 1, Timestamp: time.Now(), IP: "203.0.113.7"}) {
+		t.Fatal("Enqueue() = false, want the event journaled")
+	}
+
+	// L'adresse complète ne doit jamais être écrite sur disque.
+	segments, err := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err != nil || len(segments) == 0 {
+		t.Fatalf("no journal segment in %s (err %v)", dir, err)
+	}
+	data, err := os.ReadFile(segments[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("203.0.113.7")) || !bytes.Contains(data, []byte("203.0.113.0")) {
+		t.Errorf("journal segment = %q, want only the truncated address", data)
+	}
+}
+
+func TestClickWorkerAcksAbandonedBatch(t *testing.T) {
+	silenceLogs(t)
+
+	j, _ := openTestJournal(t)
+	repo := &flakyClickRepository{ClickRepository: repository.NewClickRepository(repositorytest.OpenDatabase(t)), failures: batchWriteRetries + 1}
+	pipeline := NewClickPipeline(repo, analytics.NewEnricher(nil, nil), PipelineOptions{
+		WorkerCount: 1,
+		Batch:       BatchOptions{Size: 3, FlushInterval: time.Minute},
+		Journal:     j,
+	})
+	pipeline.Start()
+	for i := 0; i < 3; i++ {
+		pipeline.Enqueue(models.ClickEvent{LinkID: 1, Timestamp: time.Now()})
+	}
+	if err := pipeline.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Le lot abandonné n'est pas rejoué indéfiniment : il ne bloque plus le journal.
+	stats := pipeline.Stats()
+	if stats.Failed != 3 || stats.JournalPending != 0 {
+		t.Errorf("failed=%d journal_pending=%d; want failed=3 journal_pending=0", stats.Failed, stats.JournalPending)
+	}
+}
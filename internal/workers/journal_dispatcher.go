@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/axellelanca/urlshortener/internal/journal"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// dispatchJournal relit le journal des clics et transmet les événements au channel des workers.
+// Les événements non acquittés lors de l'exécution précédente sont rejoués en premier.
+// L'envoi est bloquant : lorsque le channel est plein, les nouveaux clics restent sur disque
+// jusqu'à ce que les workers aient de la place, au lieu d'être abandonnés.
+// Le dispatcher s'arrête lorsque le journal est scellé et entièrement transmis, lorsqu'il est fermé,
+// ou à l'annulation de ctx (les événements restants seront alors rejoués).
+func (p *ClickPipeline) dispatchJournal(ctx context.Context) {
+	if pending := p.journal.Pending(); pending > 0 {
+		logger().Info("Replaying unacknowledged click events from journal", "pending", pending)
+	}
+
+	reader := p.journal.NewReader()
+	defer reader.Close()
+
+	for {
+		seq, data, err := reader.Next()
+		if errors.Is(err, io.EOF) || errors.Is(err, journal.ErrClosed) {
+			return
+		}
+		if err != nil {
+			logger().Error("Failed to read click journal, stopping replay", "error", err)
+			return
+		}
+
+		var event models.ClickEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			// Un événement illisible ne doit pas bloquer la suite du journal.
+			logger().Error("Skipping unreadable journal event", "seq", seq, "error", err)
+			p.journal.Ack(seq)
+			continue
+		}
+		event.JournalSeq = seq
+
+		select {
+		case p.events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/journal"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Valeurs par défaut du pipeline si la configuration ne les précise pas.
+const (
+	DefaultWorkerCount = 2
+	DefaultBufferSize  = 100
+)
+
+// PipelineOptions configure un ClickPipeline.
+type PipelineOptions struct {
+	WorkerCount int              // Nombre de workers écrivant les clics en base
+	BufferSize  int              // Taille du channel entre la redirection et les workers
+	Batch       BatchOptions     // Regroupement des clics avant écriture
+	Journal     *journal.Journal // Journal disque optionnel (nil = désactivé)
+}
+
+// PipelineStats est un instantané de l'activité du pipeline depuis son démarrage.
+type PipelineStats struct {
+	Queued         int   `json:"queued"`          // Événements en attente dans le channel
+	Capacity       int   `json:"capacity"`        // Taille du channel
+	InFlight       int64 `json:"in_flight"`       // Événements reçus par les workers, pas encore écrits
+	Recorded       int64 `json:"recorded"`        // Clics persistés
+	Failed         int64 `json:"failed"`          // Clics dont l'écriture a échoué
+	Dropped        int64 `json:"dropped"`         // Événements abandonnés (channel plein ou pipeline arrêté)
+	JournalPending int   `json:"journal_pending"` // Événements journalisés non acquittés (0 sans journal)
+}
+
+// ClickPipeline achemine les événements de clic de la redirection jusqu'à la base :
+// il possède le channel des événements, les workers qui les écrivent par lots
+// et, si un journal est configuré, le dispatcher qui relit ce journal.
+// Chaque serveur crée son propre pipeline, qui est injecté dans les routes de l'API.
+type ClickPipeline struct {
+	events    chan models.ClickEvent
+	clickRepo repository.ClickRepository
+	enricher  *analytics.Enricher
+	opts      PipelineOptions
+	journal   *journal.Journal
+
+	mu      sync.RWMutex // Protège l'envoi dans events contre sa fermeture par Stop
+	started bool
+	stopped bool
+
+	wg               sync.WaitGroup
+	dispatcherDone   chan struct{}
+	cancelDispatcher context.CancelFunc
+
+	received atomic.Int64
+	recorded atomic.Int64
+	failed   atomic.Int64
+	dropped  atomic.Int64
+}
+
+// NewClickPipeline crée un pipeline de clics. Les workers ne sont lancés que par Start.
+func NewClickPipeline(clickRepo repository.ClickRepository, enricher *analytics.Enricher, opts PipelineOptions) *ClickPipeline {
+	if opts.WorkerCount <= 0 {
+		opts.WorkerCount = DefaultWorkerCount
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	opts.Batch = opts.Batch.withDefaults()
+
+	return &ClickPipeline{
+		events:    make(chan models.ClickEvent, opts.BufferSize),
+		clickRepo: clickRepo,
+		enricher:  enricher,
+		opts:      opts,
+		journal:   opts.Journal,
+	}
+}
+
+// Start lance les workers et, avec un journal, le dispatcher qui rejoue puis suit ce journal.
+func (p *ClickPipeline) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+
+	logger().Info("Starting click workers",
+		"workers", p.opts.WorkerCount, "buffer", p.opts.BufferSize,
+		"batch_size", p.opts.Batch.Size, "flush_interval", p.opts.Batch.FlushInterval.String())
+	for i := 0; i < p.opts.WorkerCount; i++ {
+		p.wg.Add(1)
+		go p.clickWorker()
+	}
+
+	if p.journal != nil {
+		var ctx context.Context
+		ctx, p.cancelDispatcher = context.WithCancel(context.Background())
+		p.dispatcherDone = make(chan struct{})
+		go func() {
+			defer close(p.dispatcherDone)
+			p.dispatchJournal(ctx)
+		}()
+	}
+}
+
+// Enqueue transmet un événement de clic aux workers sans jamais bloquer l'appelant.
+// L'adresse IP est géolocalisée puis réduite selon analytics.ip_mode avant tout stockage, y compris dans le journal.
+// Avec un journal, l'événement y est écrit et les workers le reçoivent en le relisant ;
+// sans journal (ou si l'écriture échoue), il est envoyé dans le channel et abandonné si celui-ci est plein.
+// Elle retourne false si l'événement a été abandonné.
+func (p *ClickPipeline) Enqueue(event models.ClickEvent) bool {
+	p.enricher.ProtectEvent(&event)
+
+	if p.journal != nil {
+		data, err := json.Marshal(event)
+		if err == nil {
+			_, err = p.journal.Append(data)
+		}
+		if err == nil {
+			return true
+		}
+		logger().Warn("Failed to journal click event, sending it directly",
+			"short_code", event.ShortCode, "request_id", event.RequestID, "error", err)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		p.dropped.Add(1)
+		logger().Warn("Click pipeline is stopped, dropping click event",
+			"short_code", event.ShortCode, "request_id", event.RequestID)
+		return false
+	}
+
+	// Envoi non bloquant dans le channel bufferisé.
+	select {
+	case p.events <- event:
+		return true
+	default:
+		p.dropped.Add(1)
+		logger().Warn("Click events channel is full, dropping click event",
+			"short_code", event.ShortCode, "request_id", event.RequestID)
+		return false
+	}
+}
+
+// Stop arrête le pipeline : le journal n'accepte plus d'ajouts et son contenu est transmis aux workers,
+// puis le channel est fermé pour que les workers écrivent leurs derniers lots.
+// Elle retourne ctx.Err() si le contexte expire avant la fin ; les événements journalisés
+// non écrits seront alors rejoués au prochain démarrage.
+func (p *ClickPipeline) Stop(ctx context.Context) error {
+	if p.journal != nil {
+		p.journal.Seal()
+	}
+	if p.dispatcherDone != nil {
+		select {
+		case <-p.dispatcherDone:
+		case <-ctx.Done():
+			p.cancelDispatcher()
+			<-p.dispatcherDone
+		}
+	}
+
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats retourne l'activité du pipeline depuis son démarrage.
+func (p *ClickPipeline) Stats() PipelineStats {
+	stats := PipelineStats{
+		Queued:   len(p.events),
+		Capacity: cap(p.events),
+		Recorded: p.recorded.Load(),
+		Failed:   p.failed.Load(),
+		Dropped:  p.dropped.Load(),
+	}
+	stats.InFlight = p.received.Load() - stats.Recorded - stats.Failed
+	if p.journal != nil {
+		stats.JournalPending = p.journal.Pending()
+	}
+	return stats
+}
+
+// logger retourne le logger du pipeline de clics, dérivé du logger par défaut au moment de l'appel.
+func logger() *slog.Logger {
+	return slog.Default().With("component", "workers")
+}
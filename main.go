@@ -1,6 +1,8 @@
 package main
 
 import (
+	_ "time/tzdata" // Embarque la base des fuseaux horaires pour le paramètre tz des statistiques
+
 	"github.com/axellelanca/urlshortener/cmd"
 	_ "github.com/axellelanca/urlshortener/cmd/cli"    // Importe le package 'cli' pour que ses init() soient exécutés
 	_ "github.com/axellelanca/urlshortener/cmd/server" // Importe le package 'server' pour que ses init() soient exécutés